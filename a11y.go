@@ -0,0 +1,215 @@
+package main
+
+// The a11y check. It reads each built page for the structural mistakes that
+// make a page hard to use with a screen reader or keyboard, and that markdown
+// makes easy to commit: a post's own `#` heading under the template's <h1>,
+// skipped heading levels, images without alt text, links with nothing or only
+// "here" to say, tables without headers, and raw HTML left unclosed. Findings
+// on a post's page name the markdown file it came from, which is where the fix
+// goes.
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// vagueLinkTexts are link texts that say nothing out of context, which is how
+// a screen reader's list of links presents them.
+var vagueLinkTexts = map[string]bool{
+	"here": true, "click here": true, "this": true, "link": true, "this link": true,
+	"more": true, "read more": true,
+}
+
+// htmlOptionalEnd are elements whose end tag HTML lets you leave out; the
+// balance check closes them quietly when their parent closes.
+var htmlOptionalEnd = map[string]bool{
+	"p": true, "li": true, "dt": true, "dd": true, "tr": true, "td": true, "th": true,
+	"thead": true, "tbody": true, "tfoot": true, "option": true, "colgroup": true,
+	"html": true, "head": true, "body": true,
+}
+
+// a11yLink is an <a> being read, for the empty and vague link rules.
+type a11yLink struct {
+	line  int
+	label string // aria-label or title, which stand in for text
+	text  strings.Builder
+	image bool // holds an image with alt text, which names the link
+}
+
+// a11yTable is a <table> being read, for the header rule.
+type a11yTable struct {
+	line    int
+	headers bool
+}
+
+// lintPage checks one page and returns its findings, without a source.
+func lintPage(page *builtPage) []finding {
+	var findings []finding
+	report := func(line int, format string, args ...interface{}) {
+		findings = append(findings, finding{File: page.File, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ids := make(map[string]int)
+	lastLevel, h1s := 0, 0
+	var link *a11yLink
+	var tables []*a11yTable
+	type open struct {
+		name string
+		line int
+	}
+	var stack []open
+
+	for _, tok := range page.Tokens {
+		switch tok.Kind {
+		case htmlText:
+			if link != nil {
+				link.text.WriteString(tok.Text)
+			}
+			continue
+		case htmlStartTag:
+		case htmlEndTag:
+			switch tok.Name {
+			case "a":
+				if link != nil {
+					text := strings.Join(strings.Fields(html.UnescapeString(link.text.String())), " ")
+					switch {
+					case text == "" && link.label == "" && !link.image:
+						report(link.line, "link has no text for a screen reader to announce")
+					case vagueLinkTexts[strings.ToLower(strings.Trim(text, ".:!"))]:
+						report(link.line, "link text %q says nothing out of context", text)
+					}
+					link = nil
+				}
+			case "table":
+				if n := len(tables); n > 0 {
+					if !tables[n-1].headers {
+						report(tables[n-1].line, "table has no header cells (<th>)")
+					}
+					tables = tables[:n-1]
+				}
+			}
+
+			if htmlVoidElements[tok.Name] {
+				continue
+			}
+			at := -1
+			for i := len(stack) - 1; i >= 0; i-- {
+				if stack[i].name == tok.Name {
+					at = i
+					break
+				}
+			}
+			if at < 0 {
+				report(tok.Line, "</%s> closes nothing that is open", tok.Name)
+				continue
+			}
+			for _, o := range stack[at+1:] {
+				if !htmlOptionalEnd[o.name] {
+					report(o.line, "<%s> is never closed (closed by </%s> on line %d)", o.name, tok.Name, tok.Line)
+				}
+			}
+			stack = stack[:at]
+			continue
+		default:
+			continue
+		}
+
+		// A start tag.
+		if id, ok := tok.attr("id"); ok {
+			if first, dup := ids[id]; dup {
+				report(tok.Line, "id %q is already used on line %d", id, first)
+			} else {
+				ids[id] = tok.Line
+			}
+		}
+
+		switch tok.Name {
+		case "h1", "h2", "h3", "h4", "h5", "h6":
+			level := int(tok.Name[1] - '0')
+			if level == 1 {
+				h1s++
+				if h1s == 2 {
+					report(tok.Line, "second <h1> on the page: a post's top heading should be ##, under the title")
+				}
+			} else if level > lastLevel+1 {
+				if lastLevel == 0 {
+					report(tok.Line, "<%s> is the first heading; the page should open with <h1>", tok.Name)
+				} else {
+					report(tok.Line, "<%s> follows <h%d>, skipping a level", tok.Name, lastLevel)
+				}
+			}
+			lastLevel = level
+		case "img":
+			alt, ok := tok.attr("alt")
+			if !ok {
+				report(tok.Line, "image has no alt text (use alt=\"\" if it is decoration)")
+			}
+			if link != nil && strings.TrimSpace(alt) != "" {
+				link.image = true
+			}
+		case "a":
+			if _, ok := tok.attr("href"); ok {
+				label, _ := tok.attr("aria-label")
+				if label == "" {
+					label, _ = tok.attr("title")
+				}
+				link = &a11yLink{line: tok.Line, label: strings.TrimSpace(label)}
+			}
+		case "table":
+			tables = append(tables, &a11yTable{line: tok.Line})
+		case "th":
+			if n := len(tables); n > 0 {
+				tables[n-1].headers = true
+			}
+		}
+
+		if !htmlVoidElements[tok.Name] && !tok.SelfClosing {
+			stack = append(stack, open{name: tok.Name, line: tok.Line})
+		}
+	}
+
+	for _, o := range stack {
+		if !htmlOptionalEnd[o.name] {
+			report(o.line, "<%s> is never closed", o.name)
+		}
+	}
+	return findings
+}
+
+// sourcePost returns the markdown file a built page was rendered from, or ""
+// for a generated listing.
+func sourcePost(contentDir, pagePath string) string {
+	if strings.Contains(pagePath, "/") {
+		return ""
+	}
+	stem := strings.TrimSuffix(pagePath, ".html")
+	for _, ext := range []string{".md", ".markdown"} {
+		path := filepath.Join(contentDir, stem+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// checkA11y lints every page in the build output.
+func checkA11y(ctx checkContext) ([]finding, error) {
+	pages, err := readBuiltPages(ctx.buildDir)
+	if err != nil {
+		return nil, err
+	}
+	var findings []finding
+	for _, path := range sortedPagePaths(pages) {
+		source := sourcePost(ctx.contentDir, path)
+		for _, f := range lintPage(pages[path]) {
+			if source != "" {
+				f.Message += " (from " + source + ")"
+			}
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLintPage(t *testing.T) {
+	src := strings.Join([]string{
+		`<html><body><h1>Title</h1>`,
+		`<h1>Post heading</h1>`,
+		`<h2 id="a">Section</h2><h4>Deep</h4>`,
+		`<p id="a">Again</p>`,
+		`<img src="x.png"><img src="y.png" alt="">`,
+		`<a href="/x.html"></a><a href="/y.html"><img src="y.png" alt="Why"></a><a href="/z.html" aria-label="Zed"></a>`,
+		`<a href="/w.html">here</a> <a href="/v.html">Click <em>here</em>.</a> <a href="/u.html">the usual way</a>`,
+		`<table><tr><td>1</td></tr></table><table><tr><th>h</th></tr><tr><td>1</td></tr></table>`,
+		`<div><p>open <strong>bold</p></div></span>`,
+		`<ul><li>one<li>two</ul>`,
+		`<section>`,
+		`</body></html>`,
+	}, "\n")
+
+	var got []string
+	for _, f := range lintPage(&builtPage{File: "page.html", Tokens: scanHTML(src)}) {
+		got = append(got, f.String())
+	}
+	want := []string{
+		`page.html:2: second <h1> on the page: a post's top heading should be ##, under the title`,
+		`page.html:3: <h4> follows <h2>, skipping a level`,
+		`page.html:4: id "a" is already used on line 3`,
+		`page.html:5: image has no alt text (use alt="" if it is decoration)`,
+		`page.html:6: link has no text for a screen reader to announce`,
+		`page.html:7: link text "here" says nothing out of context`,
+		`page.html:7: link text "Click here." says nothing out of context`,
+		`page.html:8: table has no header cells (<th>)`,
+		`page.html:9: <strong> is never closed (closed by </p> on line 9)`,
+		`page.html:9: </span> closes nothing that is open`,
+		`page.html:11: <section> is never closed (closed by </body> on line 12)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\n\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintPageFirstHeading(t *testing.T) {
+	findings := lintPage(&builtPage{File: "p.html", Tokens: scanHTML("<h2>No title</h2><h3>ok</h3>")})
+	if len(findings) != 1 || !strings.Contains(findings[0].Message, "the page should open with <h1>") {
+		t.Errorf("findings = %v", findings)
+	}
+}
+
+// TestCheckA11ySource checks a post's findings name the markdown it came
+// from, and a generated page's don't.
+func TestCheckA11ySource(t *testing.T) {
+	buildDir := writeBuild(t, map[string]string{
+		"2023-01-01-post.html": "<h1>T</h1><img src=a.png>",
+		"tags/aws.html":        "<h1>T</h1><img src=a.png>",
+	})
+	contentDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(contentDir, "2023-01-01-post.md"), []byte("# T\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	findings, err := checkA11y(checkContext{buildDir: buildDir, contentDir: contentDir})
+	if err != nil {
+		t.Fatalf("checkA11y: %v", err)
+	}
+	if len(findings) != 2 {
+		t.Fatalf("findings = %v, want one per page", findings)
+	}
+	assertContains(t, findings[0].Message, "(from "+filepath.Join(contentDir, "2023-01-01-post.md")+")")
+	assertNotContains(t, findings[1].Message, "(from")
+}
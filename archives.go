@@ -0,0 +1,206 @@
+package main
+
+// Date archives. Every year with a post gets a page at /<year>/, listing that
+// year's posts newest first, and — when site.yaml turns them on — every month
+// with a post gets one at /<year>/<month>/. Both are index.html files in their
+// directory so the URL is the bare period. Undated posts have no period and
+// stay out, as they do everywhere datedPostsNewestFirst decides.
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// datePeriod is the posts from one year, or one month of a year, newest first.
+type datePeriod struct {
+	Year  int
+	Month int // 1-12 for a month page; 0 for a whole year
+	Posts []*BlogPost
+}
+
+// path is the build-relative path of the period's page.
+func (p datePeriod) path() string {
+	if p.Month == 0 {
+		return fmt.Sprintf("%d/index.html", p.Year)
+	}
+	return fmt.Sprintf("%d/%02d/index.html", p.Year, p.Month)
+}
+
+// url is the root-relative link to the period's page: its directory.
+func (p datePeriod) url() string {
+	return "/" + strings.TrimSuffix(p.path(), "index.html")
+}
+
+// name is how the period reads in headings: "2023" or "July 2023".
+func (p datePeriod) name() string {
+	if p.Month == 0 {
+		return fmt.Sprint(p.Year)
+	}
+	return fmt.Sprintf("%s %d", monthName(p.Month), p.Year)
+}
+
+// monthName is the English name of month m, 1-based.
+func monthName(m int) string {
+	return [...]string{"January", "February", "March", "April", "May", "June", "July",
+		"August", "September", "October", "November", "December"}[m-1]
+}
+
+// groupByYear buckets posts by year, newest year first, each bucket keeping the
+// newest-first order datedPostsNewestFirst gives it.
+func groupByYear(posts []*BlogPost) []datePeriod {
+	var years []datePeriod
+	for _, post := range datedPostsNewestFirst(posts) {
+		if n := len(years); n == 0 || years[n-1].Year != post.Date.Year() {
+			years = append(years, datePeriod{Year: post.Date.Year()})
+		}
+		years[len(years)-1].Posts = append(years[len(years)-1].Posts, post)
+	}
+	return years
+}
+
+// groupByMonth splits one year's posts by month, newest month first.
+func groupByMonth(year datePeriod) []datePeriod {
+	var months []datePeriod
+	for _, post := range year.Posts {
+		m := int(post.Date.Month())
+		if n := len(months); n == 0 || months[n-1].Month != m {
+			months = append(months, datePeriod{Year: year.Year, Month: m})
+		}
+		months[len(months)-1].Posts = append(months[len(months)-1].Posts, post)
+	}
+	return months
+}
+
+// renderPostsByYear renders posts, already newest first, as one list per year
+// under a heading that links to the year's page.
+func renderPostsByYear(posts []*BlogPost) string {
+	var b strings.Builder
+	for _, year := range groupByYear(posts) {
+		b.WriteString(fmt.Sprintf("<h2 id=\"year-%d\"><a href=\"%s\">%d</a></h2>", year.Year, year.url(), year.Year))
+		b.WriteString(renderPostList(year.Posts, false))
+	}
+	return b.String()
+}
+
+// generateDateArchives writes a page per year and, if months is set, a page
+// per month. It returns the build-relative path of every page written, for the
+// sitemap.
+func generateDateArchives(posts []*BlogPost, template, buildDir string, months bool) ([]string, error) {
+	years := groupByYear(posts)
+	var written []string
+	for i, year := range years {
+		// years runs newest first, so the newer year is the one before.
+		var newer, older *datePeriod
+		if i > 0 {
+			newer = &years[i-1]
+		}
+		if i+1 < len(years) {
+			older = &years[i+1]
+		}
+
+		var monthPages []datePeriod
+		if months {
+			monthPages = groupByMonth(year)
+		}
+
+		var body strings.Builder
+		if len(monthPages) > 0 {
+			body.WriteString("<nav class=\"tag-list\" aria-label=\"Months\">")
+			for _, month := range monthPages {
+				body.WriteString(fmt.Sprintf("<a class=\"tag\" href=\"%s\">%s<span class=\"tag-count\">%d</span></a>",
+					month.url(), monthName(month.Month), len(month.Posts)))
+			}
+			body.WriteString("</nav>")
+		}
+		body.WriteString(renderPostList(year.Posts, false))
+		body.WriteString(renderPeriodNav(older, newer))
+
+		trail := []crumb{{Name: "All posts", Path: "posts.html"}}
+		if err := writePeriodPage(year, body.String(), periodHeadLinks(older, newer), trail, template, buildDir); err != nil {
+			return written, err
+		}
+		written = append(written, year.path())
+
+		for j, month := range monthPages {
+			var body strings.Builder
+			body.WriteString(renderPostList(month.Posts, false))
+			body.WriteString(fmt.Sprintf("<p><a href=\"%s\">&larr; All of %d</a></p>", year.url(), year.Year))
+			// Month pages chain within their year only; the year page is the
+			// way across years.
+			var newerMonth, olderMonth *datePeriod
+			if j > 0 {
+				newerMonth = &monthPages[j-1]
+			}
+			if j+1 < len(monthPages) {
+				olderMonth = &monthPages[j+1]
+			}
+			monthTrail := []crumb{trail[0], {Name: year.name(), Path: year.path()}}
+			if err := writePeriodPage(month, body.String(), periodHeadLinks(olderMonth, newerMonth), monthTrail, template, buildDir); err != nil {
+				return written, err
+			}
+			written = append(written, month.path())
+		}
+	}
+
+	if len(years) > 0 {
+		fmt.Printf("Generated %d date archive pages\n", len(written))
+	}
+	return written, nil
+}
+
+// renderPeriodNav links a year page to the years either side of it.
+func renderPeriodNav(older, newer *datePeriod) string {
+	if older == nil && newer == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<nav class=\"post-nav\" aria-label=\"More years\">")
+	if older != nil {
+		b.WriteString(fmt.Sprintf("<a class=\"prev\" href=\"%s\" rel=\"prev\"><span>&larr; Older</span>%s</a>", older.url(), older.name()))
+	}
+	if newer != nil {
+		b.WriteString(fmt.Sprintf("<a class=\"next\" href=\"%s\" rel=\"next\"><span>Newer &rarr;</span>%s</a>", newer.url(), newer.name()))
+	}
+	b.WriteString("</nav>")
+	return b.String()
+}
+
+// periodHeadLinks is the rel=prev/rel=next pair for a period page's <head>,
+// prev being the older period as it is for posts.
+func periodHeadLinks(older, newer *datePeriod) string {
+	var b strings.Builder
+	if older != nil {
+		b.WriteString(fmt.Sprintf("<link rel=\"prev\" href=\"%s\" />", html.EscapeString(canonicalURL(older.path()))))
+	}
+	if newer != nil {
+		b.WriteString(fmt.Sprintf("<link rel=\"next\" href=\"%s\" />", html.EscapeString(canonicalURL(newer.path()))))
+	}
+	return b.String()
+}
+
+// writePeriodPage wraps a period's body in the template and writes it. trail is
+// the breadcrumb trail from the home page to the period's parent.
+func writePeriodPage(p datePeriod, body, headExtra string, trail []crumb, template, buildDir string) error {
+	title := "Posts from " + p.name()
+	description := fmt.Sprintf("%s on %s from %s, newest first.", pluralPosts(len(p.Posts)), siteName, p.name())
+	page := renderPage(template, pageMeta{
+		Title:       title,
+		File:        p.path(),
+		Description: description,
+		Canonical:   canonicalURL(p.path()),
+		HeadExtra:   headExtra + collectionJSONLD(title, description, p.path(), trail, postItems(p.Posts, 0)),
+		Content:     body,
+	})
+
+	outputPath := filepath.Join(buildDir, filepath.FromSlash(p.path()))
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", p.path(), err)
+	}
+	if err := os.WriteFile(outputPath, []byte(page), 0644); err != nil {
+		return fmt.Errorf("writing date archive %s: %w", p.path(), err)
+	}
+	return nil
+}
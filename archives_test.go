@@ -0,0 +1,148 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveTestPosts spans two years and, in 2023, two months, plus an undated
+// page that every date archive must leave out.
+func archiveTestPosts(t *testing.T) []*BlogPost {
+	t.Helper()
+	return []*BlogPost{
+		{Title: "About", OutputFile: "about.html"},
+		{Title: "July B", OutputFile: "2023-07-21-b.html", Date: testDate(t, "2023-07-21")},
+		{Title: "Old", OutputFile: "2022-06-14-old.html", Date: testDate(t, "2022-06-14")},
+		{Title: "July A", OutputFile: "2023-07-20-a.html", Date: testDate(t, "2023-07-20")},
+		{Title: "Feb", OutputFile: "2023-02-09-feb.html", Date: testDate(t, "2023-02-09")},
+	}
+}
+
+func TestGroupByYearAndMonth(t *testing.T) {
+	years := groupByYear(archiveTestPosts(t))
+	if len(years) != 2 || years[0].Year != 2023 || years[1].Year != 2022 {
+		t.Fatalf("years = %+v, want 2023 then 2022", years)
+	}
+	if len(years[0].Posts) != 3 || years[0].Posts[0].Title != "July B" {
+		t.Errorf("2023 holds %d posts starting %q, want 3 newest first", len(years[0].Posts), years[0].Posts[0].Title)
+	}
+
+	months := groupByMonth(years[0])
+	if len(months) != 2 || months[0].Month != 7 || len(months[0].Posts) != 2 || months[1].Month != 2 {
+		t.Errorf("2023 months = %+v, want July (2) then February (1)", months)
+	}
+
+	if got := months[0].path(); got != "2023/07/index.html" {
+		t.Errorf("month path = %q", got)
+	}
+	if got := months[0].url(); got != "/2023/07/" {
+		t.Errorf("month url = %q", got)
+	}
+	if got := months[0].name(); got != "July 2023" {
+		t.Errorf("month name = %q", got)
+	}
+	if got := years[1].url(); got != "/2022/" {
+		t.Errorf("year url = %q", got)
+	}
+}
+
+func TestRenderPostsByYear(t *testing.T) {
+	out := renderPostsByYear(datedPostsNewestFirst(archiveTestPosts(t)))
+
+	assertContains(t, out,
+		`<h2 id="year-2023"><a href="/2023/">2023</a></h2>`,
+		`<h2 id="year-2022"><a href="/2022/">2022</a></h2>`,
+	)
+	assertNotContains(t, out, "about.html")
+	if strings.Index(out, "year-2023") > strings.Index(out, "2023-02-09-feb.html") ||
+		strings.Index(out, "2023-02-09-feb.html") > strings.Index(out, "year-2022") {
+		t.Error("a post is listed outside its year's heading")
+	}
+}
+
+func TestGenerateDateArchivesYearsOnly(t *testing.T) {
+	buildDir := t.TempDir()
+
+	written, err := generateDateArchives(archiveTestPosts(t), testMetaTemplate, buildDir, false)
+	if err != nil {
+		t.Fatalf("generateDateArchives: %v", err)
+	}
+	if got := strings.Join(written, ","); got != "2023/index.html,2022/index.html" {
+		t.Errorf("written = %s", got)
+	}
+
+	page := readFile(t, filepath.Join(buildDir, "2023", "index.html"))
+	assertContains(t, page,
+		`<title>Posts from 2023</title>`,
+		`href="https://letsbuild.cloud/2023/"`,
+		`<link rel="prev" href="https://letsbuild.cloud/2022/" />`,
+		`href="/2023-02-09-feb.html"`,
+		`<a class="prev" href="/2022/" rel="prev">`,
+	)
+	assertNotContains(t, page, `rel="next"`, "2022-06-14-old.html", `aria-label="Months"`)
+
+	if _, err := os.Stat(filepath.Join(buildDir, "2023", "07")); !os.IsNotExist(err) {
+		t.Error("month pages were written with months off")
+	}
+}
+
+func TestGenerateDateArchivesWithMonths(t *testing.T) {
+	buildDir := t.TempDir()
+
+	written, err := generateDateArchives(archiveTestPosts(t), testMetaTemplate, buildDir, true)
+	if err != nil {
+		t.Fatalf("generateDateArchives: %v", err)
+	}
+	want := "2023/index.html,2023/07/index.html,2023/02/index.html,2022/index.html,2022/06/index.html"
+	if got := strings.Join(written, ","); got != want {
+		t.Errorf("written = %s, want %s", got, want)
+	}
+
+	year := readFile(t, filepath.Join(buildDir, "2023", "index.html"))
+	assertContains(t, year, `<a class="tag" href="/2023/07/">July<span class="tag-count">2</span></a>`)
+
+	july := readFile(t, filepath.Join(buildDir, "2023", "07", "index.html"))
+	assertContains(t, july,
+		`<title>Posts from July 2023</title>`,
+		`href="/2023-07-20-a.html"`,
+		`<link rel="prev" href="https://letsbuild.cloud/2023/02/" />`,
+		`<a href="/2023/">&larr; All of 2023</a>`,
+	)
+	assertNotContains(t, july, "2023-02-09-feb.html")
+}
+
+// TestGenerateSiteDateArchives checks a full build groups posts.html by year
+// and lists the year pages in the sitemap.
+func TestGenerateSiteDateArchives(t *testing.T) {
+	testDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Could not change to test directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	buildDir := filepath.Join(testDir, "build")
+	if err := generateSite(filepath.Join(testDir, "content"), buildDir, filepath.Join(testDir, "template.html"), nil); err != nil {
+		t.Fatalf("generateSite: %v", err)
+	}
+
+	archive := readFile(t, filepath.Join(buildDir, "posts.html"))
+	sitemap := readFile(t, filepath.Join(buildDir, "sitemap.xml"))
+	entries, _ := os.ReadDir(buildDir)
+	found := false
+	for _, e := range entries {
+		if !e.IsDir() || len(e.Name()) != 4 {
+			continue
+		}
+		found = true
+		assertContains(t, archive, `<a href="/`+e.Name()+`/">`+e.Name()+`</a>`)
+		assertContains(t, sitemap, "https://letsbuild.cloud/"+e.Name()+"/</loc>")
+	}
+	if !found {
+		t.Error("no year directories were written")
+	}
+}
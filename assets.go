@@ -0,0 +1,324 @@
+package main
+
+// Asset fingerprinting. theme.css is served as /theme.css, so after a change
+// to the theme a reader's browser goes on using the copy it cached until that
+// expires. With assets.fingerprint set, every stylesheet, script, image and
+// font in static/ is also written under a name carrying a hash of its
+// content — theme.css as theme.3f2a9c01de.css — and every page's reference to
+// it is rewritten to that name, so a changed file is a new URL and can be
+// cached for good. The unhashed copy stays, for pages cached from before and
+// for the feeds, which carry their own copy of each post.
+//
+// The template reaches an asset through the map with {{asset:/theme.css}},
+// which is the plain path when fingerprinting is off. Hashed files no page
+// refers to any more — an old theme's, say — are removed from the build. Only
+// files a build hashed itself are ever removed: .cache/assets.json records
+// them, so a static file that merely looks hashed, logo.deadbeef00.png, stays.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// assetExtensions are the static files fingerprinted. HTML pages keep their
+// names: they are what the URLs in links and search results point at.
+var assetExtensions = map[string]bool{
+	".css": true, ".js": true, ".mjs": true, ".png": true, ".jpg": true, ".jpeg": true, ".gif": true,
+	".svg": true, ".webp": true, ".avif": true, ".ico": true, ".woff": true, ".woff2": true,
+}
+
+// assetHashLength is how many hex digits of the content hash a name carries.
+const assetHashLength = 10
+
+// assetManifestPath lists the fingerprinted files the last build left in the
+// build directory, relative to the working directory, so the next build knows
+// which of the files there it may prune.
+var assetManifestPath = filepath.Join(".cache", "assets.json")
+
+// assetMap maps an asset's site path, /theme.css, to its fingerprinted one,
+// /theme.3f2a9c01de.css.
+type assetMap map[string]string
+
+// hashedPaths is the set of fingerprinted site paths in the map.
+func (a assetMap) hashedPaths() map[string]bool {
+	hashed := make(map[string]bool, len(a))
+	for _, h := range a {
+		hashed[h] = true
+	}
+	return hashed
+}
+
+// loadAssetManifest reads the fingerprinted site paths an earlier build
+// recorded at path. A missing or unreadable manifest records none, so nothing
+// is pruned that this build didn't write.
+func loadAssetManifest(path string) []string {
+	var hashed []string
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &hashed); err != nil {
+		return nil
+	}
+	return hashed
+}
+
+// saveAssetManifest writes the fingerprinted site paths left in the build to
+// path.
+func saveAssetManifest(path string, hashed []string) error {
+	sort.Strings(hashed)
+	data, err := json.MarshalIndent(hashed, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// hashedAssetPath inserts the hash of data before the extension of sitePath.
+func hashedAssetPath(sitePath string, data []byte) string {
+	sum := sha256.Sum256(data)
+	ext := path.Ext(sitePath)
+	return strings.TrimSuffix(sitePath, ext) + "." + hex.EncodeToString(sum[:])[:assetHashLength] + ext
+}
+
+// resolveAssetRef turns a reference in a page or stylesheet at baseDir, a
+// site path's directory, into the site path it names and whatever query or
+// fragment follows. ok is false for a reference off the site or to a data URL.
+func resolveAssetRef(ref, baseDir string) (sitePath, suffix string, ok bool) {
+	ref = strings.TrimPrefix(ref, siteURL)
+	if ref == "" || strings.HasPrefix(ref, "//") || strings.HasPrefix(ref, "#") || strings.Contains(strings.SplitN(ref, "/", 2)[0], ":") {
+		return "", "", false
+	}
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref, suffix = ref[:i], ref[i:]
+	}
+	if !strings.HasPrefix(ref, "/") {
+		ref = path.Join(baseDir, ref)
+	}
+	return path.Clean(ref), suffix, true
+}
+
+// staticAssets lists the build-relative paths of the assets in staticDir.
+func staticAssets(staticDir string) ([]string, error) {
+	var assets []string
+	if _, err := os.Stat(staticDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+	err := filepath.WalkDir(staticDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !assetExtensions[strings.ToLower(filepath.Ext(p))] {
+			return err
+		}
+		rel, err := filepath.Rel(staticDir, p)
+		if err != nil {
+			return err
+		}
+		assets = append(assets, filepath.ToSlash(rel))
+		return nil
+	})
+	sort.Strings(assets)
+	return assets, err
+}
+
+// cssURLRe matches a url() in a stylesheet.
+var cssURLRe = regexp.MustCompile(`url\(\s*(['"]?)([^'")\s]+)(['"]?)\s*\)`)
+
+// rewriteCSSRefs points a stylesheet's url()s at fingerprinted assets.
+func rewriteCSSRefs(css, baseDir string, assets assetMap) string {
+	return cssURLRe.ReplaceAllStringFunc(css, func(m string) string {
+		parts := cssURLRe.FindStringSubmatch(m)
+		sitePath, suffix, ok := resolveAssetRef(parts[2], baseDir)
+		if hashed := assets[sitePath]; ok && hashed != "" {
+			return "url(" + parts[1] + hashed + suffix + parts[3] + ")"
+		}
+		return m
+	})
+}
+
+// fingerprintAssets writes a fingerprinted copy of every asset copyStaticDir
+// put in buildDir and returns the map from old paths to new. Images and fonts
+// go first, so the stylesheets that refer to them can be rewritten before
+// they are hashed in turn; a stylesheet is minified first when the build will
+// minify it, so the hash is of the bytes served.
+func fingerprintAssets(staticDir, buildDir string, minifyCSSFiles bool) (assetMap, error) {
+	rels, err := staticAssets(staticDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(rels, func(i, j int) bool {
+		return path.Ext(rels[i]) != ".css" && path.Ext(rels[j]) == ".css"
+	})
+
+	assets := make(assetMap, len(rels))
+	for _, rel := range rels {
+		data, err := os.ReadFile(filepath.Join(buildDir, filepath.FromSlash(rel)))
+		if err != nil {
+			return assets, err
+		}
+		if path.Ext(rel) == ".css" {
+			css := rewriteCSSRefs(string(data), path.Dir("/"+rel), assets)
+			if minifyCSSFiles {
+				css = minifyCSS(css)
+			}
+			data = []byte(css)
+		}
+		hashed := hashedAssetPath("/"+rel, data)
+		if err := os.WriteFile(filepath.Join(buildDir, filepath.FromSlash(strings.TrimPrefix(hashed, "/"))), data, 0644); err != nil {
+			return assets, err
+		}
+		assets["/"+rel] = hashed
+	}
+	return assets, nil
+}
+
+// assetPlaceholderRe matches the template's {{asset:/path}}.
+var assetPlaceholderRe = regexp.MustCompile(`\{\{asset:([^}]+)\}\}`)
+
+// resolveAssetPlaceholders fills in the template's asset placeholders from
+// the map, leaving the plain path for an asset the map doesn't have.
+func resolveAssetPlaceholders(template string, assets assetMap) string {
+	return assetPlaceholderRe.ReplaceAllStringFunc(template, func(m string) string {
+		sitePath := "/" + strings.TrimPrefix(strings.TrimSpace(assetPlaceholderRe.FindStringSubmatch(m)[1]), "/")
+		if hashed, ok := assets[sitePath]; ok {
+			return html.EscapeString(hashed)
+		}
+		return html.EscapeString(sitePath)
+	})
+}
+
+// assetRefAttrRe matches an href or src attribute in a tag's source.
+var assetRefAttrRe = regexp.MustCompile(`(?i)(\s(?:href|src)\s*=\s*)("[^"]*"|'[^']*'|[^\s"'>]+)`)
+
+// rewriteAssetRefs points the href and src attributes of page, at the site
+// path pagePath, at fingerprinted assets, and records each site path the page
+// then refers to in used. Only tags are read, so a reference quoted in a code
+// block is left alone.
+func rewriteAssetRefs(page, pagePath string, assets assetMap, used map[string]bool) string {
+	baseDir := path.Dir("/" + pagePath)
+	var b strings.Builder
+	last := 0
+	for _, tok := range scanHTML(page) {
+		if tok.Kind != htmlStartTag {
+			continue
+		}
+		raw := page[tok.Start:tok.End]
+		rewritten := assetRefAttrRe.ReplaceAllStringFunc(raw, func(m string) string {
+			parts := assetRefAttrRe.FindStringSubmatch(m)
+			value := strings.Trim(parts[2], `"'`)
+			sitePath, suffix, ok := resolveAssetRef(html.UnescapeString(value), baseDir)
+			hashed := assets[sitePath]
+			if !ok || hashed == "" {
+				if ok {
+					used[sitePath] = true
+				}
+				return m
+			}
+			used[hashed] = true
+			if strings.HasPrefix(value, siteURL) {
+				hashed = siteURL + hashed
+			}
+			return parts[1] + "\"" + html.EscapeString(hashed+suffix) + "\""
+		})
+		if rewritten != raw {
+			b.WriteString(page[last:tok.Start])
+			b.WriteString(rewritten)
+			last = tok.End
+		}
+	}
+	if last == 0 {
+		return page
+	}
+	b.WriteString(page[last:])
+	return b.String()
+}
+
+// rewriteBuildAssets points every page in buildDir at the fingerprinted
+// assets, then removes the fingerprinted files nothing refers to: an asset's
+// earlier versions, and any asset no page or stylesheet uses. The files it
+// may remove are this build's hashed copies and those the manifest at
+// assetManifestPath says earlier builds wrote; the ones kept are recorded
+// there for the next build.
+func rewriteBuildAssets(buildDir string, assets assetMap) error {
+	used := make(map[string]bool)
+	pages := 0
+	err := filepath.WalkDir(buildDir, func(p string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(buildDir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if path.Ext(rel) != ".html" {
+			return nil
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		out := rewriteAssetRefs(string(data), rel, assets, used)
+		if out == string(data) {
+			return nil
+		}
+		pages++
+		return os.WriteFile(p, []byte(out), 0644)
+	})
+	if err != nil {
+		return err
+	}
+
+	// A stylesheet a page uses keeps the fonts and images it refers to.
+	for _, hashed := range assets {
+		if !used[hashed] || path.Ext(hashed) != ".css" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(buildDir, filepath.FromSlash(strings.TrimPrefix(hashed, "/"))))
+		if err != nil {
+			return err
+		}
+		for _, m := range cssURLRe.FindAllStringSubmatch(string(data), -1) {
+			if sitePath, _, ok := resolveAssetRef(m[2], path.Dir(hashed)); ok {
+				used[sitePath] = true
+			}
+		}
+	}
+
+	hashed := assets.hashedPaths()
+	for _, sitePath := range loadAssetManifest(assetManifestPath) {
+		hashed[sitePath] = true
+	}
+	pruned := 0
+	var kept []string
+	for sitePath := range hashed {
+		p := filepath.Join(buildDir, filepath.FromSlash(strings.TrimPrefix(sitePath, "/")))
+		if used[sitePath] {
+			if _, err := os.Stat(p); err == nil {
+				kept = append(kept, sitePath)
+			}
+			continue
+		}
+		if err := os.Remove(p); err == nil {
+			pruned++
+		} else if !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := saveAssetManifest(assetManifestPath, kept); err != nil {
+		return err
+	}
+
+	fmt.Printf("Fingerprinted %d assets: %d pages rewritten, %d unreferenced hashed files removed\n", len(assets), pages, pruned)
+	return nil
+}
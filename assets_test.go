@@ -0,0 +1,157 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestResolveAssetRef(t *testing.T) {
+	tests := []struct {
+		ref, base, want, suffix string
+		ok                      bool
+	}{
+		{"/theme.css", "/", "/theme.css", "", true},
+		{"theme.css?v=2#x", "/posts", "/posts/theme.css", "?v=2#x", true},
+		{"../img/logo.png", "/notes/deep", "/notes/img/logo.png", "", true},
+		{siteURL + "/search.js", "/", "/search.js", "", true},
+		{"https://example.com/theme.css", "/", "", "", false},
+		{"//cdn.example.com/a.js", "/", "", "", false},
+		{"data:image/png;base64,AAAA", "/", "", "", false},
+		{"#top", "/", "", "", false},
+	}
+	for _, tt := range tests {
+		got, suffix, ok := resolveAssetRef(tt.ref, tt.base)
+		if got != tt.want || suffix != tt.suffix || ok != tt.ok {
+			t.Errorf("resolveAssetRef(%q, %q) = %q, %q, %v", tt.ref, tt.base, got, suffix, ok)
+		}
+	}
+}
+
+func TestFingerprintAssets(t *testing.T) {
+	staticDir := writeBuild(t, map[string]string{
+		"theme.css":        "body {\n  background: url(\"img/bg.png\");\n}\n",
+		"search.js":        "var a = 1;\n",
+		"img/bg.png":       "\x89PNG  ",
+		"style-guide.html": "<p>guide</p>",
+	})
+	buildDir := t.TempDir()
+	if _, err := copyStaticDir(staticDir, buildDir); err != nil {
+		t.Fatal(err)
+	}
+	assets, err := fingerprintAssets(staticDir, buildDir, true)
+	if err != nil {
+		t.Fatalf("fingerprintAssets: %v", err)
+	}
+	if len(assets) != 3 {
+		t.Fatalf("got %d assets, want 3: %v", len(assets), assets)
+	}
+	hashedName := regexp.MustCompile(`\.[0-9a-f]{10}\.[a-z0-9]+$`)
+	for plain, hashed := range assets {
+		if !hashedName.MatchString(hashed) || filepath.Ext(hashed) != filepath.Ext(plain) {
+			t.Errorf("%s → %s", plain, hashed)
+		}
+		if _, err := os.Stat(filepath.Join(buildDir, filepath.FromSlash(plain))); err != nil {
+			t.Errorf("the unhashed %s is gone: %v", plain, err)
+		}
+	}
+	css := readFile(t, filepath.Join(buildDir, strings.TrimPrefix(assets["/theme.css"], "/")))
+	if want := `body{background: url("` + assets["/img/bg.png"] + `")}`; css != want {
+		t.Errorf("hashed theme.css = %q, want %q", css, want)
+	}
+
+	// The same content hashes to the same name, and a change to a new one.
+	again, err := fingerprintAssets(staticDir, buildDir, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again["/theme.css"] != assets["/theme.css"] {
+		t.Errorf("rebuild renamed theme.css: %s, %s", assets["/theme.css"], again["/theme.css"])
+	}
+	if err := os.WriteFile(filepath.Join(buildDir, "search.js"), []byte("var a = 2;\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed, _ := fingerprintAssets(staticDir, buildDir, true); changed["/search.js"] == assets["/search.js"] {
+		t.Errorf("changed search.js kept its name %s", assets["/search.js"])
+	}
+}
+
+func TestResolveAssetPlaceholders(t *testing.T) {
+	template := `<link href="{{asset:/theme.css}}" /><script src="{{asset:search.js}}"></script>`
+	got := resolveAssetPlaceholders(template, assetMap{"/theme.css": "/theme.0123456789.css"})
+	if want := `<link href="/theme.0123456789.css" /><script src="/search.js"></script>`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRewriteAssetRefs(t *testing.T) {
+	assets := assetMap{"/theme.css": "/theme.0123456789.css", "/search.js": "/search.abcdef0123.js"}
+	page := `<link rel="stylesheet" href="../theme.css?v=1" />` +
+		`<script src='/search.js' defer></script>` +
+		`<a href="` + siteURL + `/theme.css">theme</a>` +
+		`<a href="/posts.html">posts</a>` +
+		`<pre><code>&lt;link href="/theme.css"&gt;</code></pre>`
+	used := map[string]bool{}
+	got := rewriteAssetRefs(page, "tags/aws.html", assets, used)
+	assertContains(t, got,
+		`<link rel="stylesheet" href="/theme.0123456789.css?v=1" />`,
+		`<script src="/search.abcdef0123.js" defer></script>`,
+		`<a href="`+siteURL+`/theme.0123456789.css">theme</a>`,
+		`<a href="/posts.html">posts</a>`,
+		`<code>&lt;link href="/theme.css"&gt;</code>`)
+	if !used["/theme.0123456789.css"] || !used["/search.abcdef0123.js"] || !used["/posts.html"] || len(used) != 3 {
+		t.Errorf("used = %v", used)
+	}
+}
+
+// TestRewriteBuildAssetsPrunes checks the hashed files an earlier build or
+// this one wrote are removed once nothing refers to them, that a static file
+// which only looks hashed is never touched, and that the manifest records
+// what was kept.
+func TestRewriteBuildAssetsPrunes(t *testing.T) {
+	original := assetManifestPath
+	assetManifestPath = filepath.Join(t.TempDir(), "assets.json")
+	defer func() { assetManifestPath = original }()
+	if err := saveAssetManifest(assetManifestPath, []string{"/theme.9876543210.css", "/img/old.3333333333.png", "/img/gone.4444444444.png"}); err != nil {
+		t.Fatal(err)
+	}
+
+	buildDir := writeBuild(t, map[string]string{
+		"index.html":              `<link href="/theme.css" /><img src="/img/logo.png" alt="">`,
+		"theme.css":               "p{}",
+		"theme.0123456789.css":    `p{background:url(/img/bg.1111111111.png)}`,
+		"theme.9876543210.css":    "old",
+		"search.abcdef0123.js":    "unused",
+		"img/bg.1111111111.png":   "bg",
+		"img/logo.2222222222.png": "logo",
+		"img/old.3333333333.png":  "old",
+		"img/hand.deadbeef00.png": "named by hand",
+		"cards/index.png":         "card",
+	})
+	assets := assetMap{
+		"/theme.css": "/theme.0123456789.css", "/search.js": "/search.abcdef0123.js",
+		"/img/logo.png": "/img/logo.2222222222.png", "/img/bg.png": "/img/bg.1111111111.png",
+	}
+	if err := rewriteBuildAssets(buildDir, assets); err != nil {
+		t.Fatalf("rewriteBuildAssets: %v", err)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "index.html")); got != `<link href="/theme.0123456789.css" /><img src="/img/logo.2222222222.png" alt="">` {
+		t.Errorf("index.html = %q", got)
+	}
+	for name, kept := range map[string]bool{
+		"theme.css": true, "theme.0123456789.css": true, "img/bg.1111111111.png": true, "img/logo.2222222222.png": true,
+		"cards/index.png": true, "img/hand.deadbeef00.png": true,
+		"theme.9876543210.css": false, "search.abcdef0123.js": false, "img/old.3333333333.png": false,
+	} {
+		_, err := os.Stat(filepath.Join(buildDir, filepath.FromSlash(name)))
+		if exists := err == nil; exists != kept {
+			t.Errorf("%s: exists = %v, want %v", name, exists, kept)
+		}
+	}
+	want := "/img/bg.1111111111.png /img/logo.2222222222.png /theme.0123456789.css"
+	if got := strings.Join(loadAssetManifest(assetManifestPath), " "); got != want {
+		t.Errorf("manifest = %s, want %s", got, want)
+	}
+}
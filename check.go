@@ -0,0 +1,275 @@
+package main
+
+// Subcommands and the checks. With no arguments the binary builds the site, as
+// it always has; `ssg check` instead reads back what a build produced (and the
+// content it came from) and reports what is wrong with it. Each check is one
+// entry in siteChecks, run by name or all together.
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// command is one subcommand of the binary.
+type command struct {
+	name  string
+	about string
+	run   func(args []string, stdout io.Writer) int // returns the exit status
+}
+
+// commands lists the subcommands in the order usage shows them.
+func commands() []command {
+	return []command{
+		{"new", "scaffold a post in content/ from an archetype; see `new -h`", runNew},
+		{"check", "check the build output and content; see `check -h`", runCheck},
+		{"lint", "lint the writing in content/; see `lint -h`", runLint},
+		{"report", "report readability numbers for each post; see `report -h`", runReport},
+	}
+}
+
+// runCommand dispatches args[0] to its subcommand and returns the exit status.
+func runCommand(args []string, stdout io.Writer) int {
+	for _, cmd := range commands() {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\nUsage: ssg [command]\n\nWith no command, builds the site into build/.\n\n", args[0])
+	for _, cmd := range commands() {
+		fmt.Fprintf(os.Stderr, "  %-8s %s\n", cmd.name, cmd.about)
+	}
+	return 2
+}
+
+// parseInterspersed parses args with fs, allowing flags after positional
+// arguments as well as before: `check links -strict` reads as naturally as
+// `check -strict links`. It returns the positional arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// finding is one problem a check found, located in the file it came from.
+type finding struct {
+	File    string // as the check opened it: build/posts.html, content/about.md
+	Line    int    // 1-based; 0 when the problem belongs to the whole file
+	Message string
+}
+
+// String formats the finding the way compilers do, so editors can jump to it.
+func (f finding) String() string {
+	if f.Line == 0 {
+		return fmt.Sprintf("%s: %s", f.File, f.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", f.File, f.Line, f.Message)
+}
+
+// sortFindings orders findings by file, then line, so a report reads down
+// each page in turn.
+func sortFindings(findings []finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+}
+
+// checkContext is where the checks find the site, and how it is configured.
+type checkContext struct {
+	buildDir   string
+	contentDir string
+	cfg        siteConfig
+	stdout     io.Writer // where a check reports its progress, beside its findings
+}
+
+// siteCheck is one named check. An opt-in check runs only when named: it is
+// slow, or reaches outside the machine. A content check reads content/ alone,
+// so it runs without a build.
+type siteCheck struct {
+	name    string
+	about   string
+	optIn   bool
+	content bool
+	run     func(ctx checkContext) ([]finding, error)
+}
+
+// siteChecks lists every check in the order `ssg check` runs them.
+func siteChecks() []siteCheck {
+	return []siteCheck{
+		{"frontmatter", "known keys of the right type, title and description lengths, no two posts sharing a title, slug or page", false, true, checkFrontmatter},
+		{"links", "internal links, images and #fragments in the build output resolve", false, false, checkLinks},
+		{"a11y", "headings, ids, alt text, link text, table headers and balanced tags in the build output", false, false, checkA11y},
+		{"external", "outbound links answer; requests every URL not cached (opt-in)", true, false, checkExternalLinks},
+	}
+}
+
+// runCheck runs the named checks, or every one that isn't opt-in, and prints
+// what each found. Findings are warnings unless -strict is given, when any one
+// fails the run; a check that can't run at all always does.
+func runCheck(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "exit non-zero if any check finds a problem")
+	buildDir := fs.String("build", "build", "the build output to check")
+	contentDir := fs.String("content", "content", "the content the build came from")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ssg check [flags] [check ...]\n\nChecks, all but the opt-in ones run when none is named:\n")
+		for _, c := range siteChecks() {
+			fmt.Fprintf(fs.Output(), "  %-12s %s\n", c.name, c.about)
+		}
+		fmt.Fprintf(fs.Output(), "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	names, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+
+	var selected []siteCheck
+	if len(names) == 0 {
+		for _, c := range siteChecks() {
+			if !c.optIn {
+				selected = append(selected, c)
+			}
+		}
+	} else {
+		byName := make(map[string]siteCheck)
+		for _, c := range siteChecks() {
+			byName[c.name] = c
+		}
+		for _, name := range names {
+			c, ok := byName[name]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "unknown check %q\n", name)
+				fs.Usage()
+				return 2
+			}
+			selected = append(selected, c)
+		}
+	}
+
+	for _, c := range selected {
+		if c.content {
+			continue
+		}
+		if info, err := os.Stat(*buildDir); err != nil || !info.IsDir() {
+			fmt.Fprintf(os.Stderr, "no build output at %s: build the site first\n", *buildDir)
+			return 1
+		}
+		break
+	}
+
+	cfg, err := loadSiteConfig(siteConfigPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx := checkContext{buildDir: filepath.Clean(*buildDir), contentDir: filepath.Clean(*contentDir), cfg: cfg, stdout: stdout}
+	status, total := 0, 0
+	for _, c := range selected {
+		findings, err := c.run(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "check %s: %v\n", c.name, err)
+			status = 1
+			continue
+		}
+		sortFindings(findings)
+		for _, f := range findings {
+			fmt.Fprintln(stdout, f)
+		}
+		if len(findings) == 0 {
+			fmt.Fprintf(stdout, "%s: ok\n", c.name)
+		} else {
+			fmt.Fprintf(stdout, "%s: %d %s\n", c.name, len(findings), plural(len(findings), "problem", "problems"))
+		}
+		total += len(findings)
+	}
+
+	if *strict && total > 0 {
+		status = 1
+	}
+	return status
+}
+
+// plural picks the noun form for n.
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+// builtPage is one HTML file from the build output, tokenized.
+type builtPage struct {
+	Path   string // build-relative, with forward slashes
+	File   string // as opened, for reporting
+	Tokens []htmlToken
+	IDs    map[string]bool // every id, and every <a name>, a fragment can target
+}
+
+// readBuiltPages tokenizes every .html file under buildDir, keyed by its
+// build-relative path.
+func readBuiltPages(buildDir string) (map[string]*builtPage, error) {
+	pages := make(map[string]*builtPage)
+	err := filepath.WalkDir(buildDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, ".html") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(buildDir, path)
+		if err != nil {
+			return err
+		}
+		page := &builtPage{Path: filepath.ToSlash(rel), File: path, Tokens: scanHTML(string(src)), IDs: make(map[string]bool)}
+		for _, tok := range page.Tokens {
+			if tok.Kind != htmlStartTag {
+				continue
+			}
+			if id, ok := tok.attr("id"); ok {
+				page.IDs[id] = true
+			}
+			if name, ok := tok.attr("name"); ok && tok.Name == "a" {
+				page.IDs[name] = true
+			}
+		}
+		pages[page.Path] = page
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("reading build output: %w", err)
+	}
+	return pages, nil
+}
+
+// sortedPagePaths returns the keys of pages in order, so checks report
+// deterministically.
+func sortedPagePaths(pages map[string]*builtPage) []string {
+	paths := make([]string, 0, len(pages))
+	for path := range pages {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	return paths
+}
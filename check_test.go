@@ -0,0 +1,52 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseInterspersed(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "")
+	build := fs.String("build", "build", "")
+
+	names, err := parseInterspersed(fs, []string{"links", "-strict", "a11y", "-build", "out"})
+	if err != nil {
+		t.Fatalf("parseInterspersed: %v", err)
+	}
+	if strings.Join(names, ",") != "links,a11y" || !*strict || *build != "out" {
+		t.Errorf("names %v, strict %v, build %q", names, *strict, *build)
+	}
+}
+
+// TestRunCheckStrict checks findings are reported either way, and only fail
+// the run under -strict.
+func TestRunCheckStrict(t *testing.T) {
+	buildDir := writeBuild(t, map[string]string{
+		"index.html": "<a href=\"/missing.html\">gone</a>",
+	})
+
+	var out bytes.Buffer
+	if status := runCheck([]string{"links", "-build", buildDir}, &out); status != 0 {
+		t.Errorf("status = %d without -strict, want 0", status)
+	}
+	assertContains(t, out.String(),
+		filepath.Join(buildDir, "index.html")+`:1: broken link "/missing.html"`,
+		"links: 1 problem\n",
+	)
+
+	out.Reset()
+	if status := runCheck([]string{"-strict", "-build", buildDir}, &out); status != 1 {
+		t.Errorf("status = %d with -strict, want 1", status)
+	}
+
+	if status := runCheck([]string{"nonsense", "-build", buildDir}, &out); status != 2 {
+		t.Errorf("status = %d for an unknown check, want 2", status)
+	}
+	if status := runCheck([]string{"-build", filepath.Join(buildDir, "absent")}, &out); status != 1 {
+		t.Errorf("status = %d with no build output, want 1", status)
+	}
+}
@@ -0,0 +1,267 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// siteConfigPath is the optional build configuration. Like static/ and
+// content/home.html it is read relative to the working directory, and a missing
+// file is not an error: every option has a default that matches how the site
+// built before the option existed.
+const siteConfigPath = "site.yaml"
+
+// siteConfig holds the opt-in build options from site.yaml. Site identity —
+// the URL, name and author — stays in the constants in main.go; this file is
+// only for switches that change what the build produces and how it is checked.
+type siteConfig struct {
+	Feed       feedConfig       `yaml:"feed"`
+	Pagination paginationConfig `yaml:"pagination"`
+	Archives   archivesConfig   `yaml:"archives"`
+	Dates      datesConfig      `yaml:"dates"`
+	Minify     minifyConfig     `yaml:"minify"`
+	Assets     assetsConfig     `yaml:"assets"`
+	Taxonomies []taxonomyConfig `yaml:"taxonomies"`
+	Links      linksConfig      `yaml:"links"`
+	Lint       lintConfig       `yaml:"lint"`
+	Report     reportConfig     `yaml:"report"`
+}
+
+// feedConfig controls the syndication feeds.
+type feedConfig struct {
+	// FullContent puts each post's rendered HTML in content:encoded alongside
+	// the summary, with root-relative links made absolute.
+	FullContent bool `yaml:"full_content"`
+}
+
+// paginationConfig sets how many posts each listing shows per page. Zero, the
+// default, keeps every post on a single page.
+type paginationConfig struct {
+	Archive int `yaml:"archive"` // posts.html
+	Tags    int `yaml:"tags"`    // each tags/<tag>.html
+}
+
+// archivesConfig controls the date archive pages. Year pages are always
+// written; month pages are opt-in, since most months hold a post or none.
+type archivesConfig struct {
+	Months bool `yaml:"months"`
+}
+
+// datesConfig controls where last-modified dates come from. Both are off by
+// default: a post's only date is then the one in its filename.
+type datesConfig struct {
+	// FromGit reads each source file's last commit date from git history, for
+	// the sitemap, article:modified_time and the feeds.
+	FromGit bool `yaml:"from_git"`
+	// ShowEdited adds a "last edited" line to a post edited after the day it
+	// was published. It needs FromGit.
+	ShowEdited bool `yaml:"show_edited"`
+}
+
+// minifyConfig switches on minification of the build output, by file type.
+type minifyConfig struct {
+	HTML bool `yaml:"html"` // every page, generated or copied from static/
+	CSS  bool `yaml:"css"`  // theme.css and any other stylesheet
+}
+
+// assetsConfig controls how the static assets are served.
+type assetsConfig struct {
+	// Fingerprint also writes each stylesheet, script, image and font under a
+	// name carrying a hash of its content, and points every page at that name.
+	Fingerprint bool `yaml:"fingerprint"`
+}
+
+// taxonomyConfig declares one way of classifying posts. Each gets a term page
+// and feed per term, an index page, and chips on the posts filed under it.
+type taxonomyConfig struct {
+	// Name is the frontmatter key and the URL directory: "stack" reads
+	// `stack: go aws` and writes stack/go.html, indexed by stack.html.
+	Name string `yaml:"name"`
+	// Singular names one term in prose: "tag", "technology".
+	Singular string `yaml:"singular"`
+	// Title heads the index page and labels the chips: "Tags", "Stack".
+	Title string `yaml:"title"`
+	// Label prefixes a term page's title and, lowercased, describes it:
+	// "Tagged" gives "Tagged: aws" and "3 posts tagged "aws"".
+	Label string `yaml:"label"`
+}
+
+// linksConfig controls `ssg check` over links.
+type linksConfig struct {
+	External externalLinksConfig `yaml:"external"`
+}
+
+// externalLinksConfig tunes the external link check, which requests every
+// outbound URL the build links to.
+type externalLinksConfig struct {
+	// Allow lists hosts known to be flaky, or to refuse robots, whose links
+	// are never requested. A host also covers its subdomains.
+	Allow []string `yaml:"allow"`
+	// CacheTTL is how long a result is trusted before the URL is requested
+	// again.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Concurrency bounds the requests in flight at once.
+	Concurrency int `yaml:"concurrency"`
+	// HostInterval is the least time between two requests to one host.
+	HostInterval time.Duration `yaml:"host_interval"`
+	// Timeout bounds each request, redirects included.
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// lintConfig controls `ssg lint`.
+type lintConfig struct {
+	Prose proseLintConfig `yaml:"prose"`
+}
+
+// proseLintConfig tunes the prose linter's rules.
+type proseLintConfig struct {
+	// MaxSentenceWords is the long-sentence limit; 0 keeps the default.
+	MaxSentenceWords int `yaml:"max_sentence_words"`
+	// Rules sets a rule's severity — error, warning or off — by name.
+	Rules map[string]string `yaml:"rules"`
+}
+
+// reportConfig controls `ssg report`.
+type reportConfig struct {
+	Readability readabilityConfig `yaml:"readability"`
+}
+
+// readabilityConfig sets the thresholds a post must meet for `ssg report
+// readability` to pass. Each is off while zero.
+type readabilityConfig struct {
+	MinFlesch           float64 `yaml:"min_flesch"`
+	MaxAvgSentenceWords float64 `yaml:"max_avg_sentence_words"`
+	MaxPassiveRatio     float64 `yaml:"max_passive_ratio"` // 0.1 is one sentence in ten
+}
+
+// reservedTaxonomyNames are the build paths the generator's own pages already
+// own, and the frontmatter keys that already mean something else. A taxonomy
+// called "posts" would write posts/2.html over the archive's second page; one
+// called "title" would never see a term. The site's own pages are added to
+// these when the config is loaded; see sitePageNames.
+var reservedTaxonomyNames = map[string]bool{
+	"posts": true, "series": true, "search": true, "index": true, "feed": true,
+	"sitemap": true, "robots": true, "404": true, "cards": true,
+	"title": true, "description": true, "related": true,
+}
+
+// sitePageNames maps the top-level names the site's own files take in the
+// build to the file that takes each: a page for every post in contentDir, and
+// one for every page or directory in staticDir. A taxonomy of the same name
+// would write <name>.html over the page, or its terms into the directory.
+// Missing directories own nothing.
+func sitePageNames(contentDir, staticDir string) map[string]string {
+	names := make(map[string]string)
+	if entries, err := os.ReadDir(contentDir); err == nil {
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if !entry.IsDir() && (ext == ".md" || ext == ".markdown") {
+				names[strings.TrimSuffix(entry.Name(), ext)] = filepath.Join(contentDir, entry.Name())
+			}
+		}
+	}
+	if entries, err := os.ReadDir(staticDir); err == nil {
+		for _, entry := range entries {
+			switch {
+			case entry.IsDir():
+				names[entry.Name()] = filepath.Join(staticDir, entry.Name())
+			case filepath.Ext(entry.Name()) == ".html":
+				names[strings.TrimSuffix(entry.Name(), ".html")] = filepath.Join(staticDir, entry.Name())
+			}
+		}
+	}
+	return names
+}
+
+// defaultSiteConfig is the configuration used when site.yaml is absent, and the
+// base a present file is decoded over. Tags are the one taxonomy a site has
+// unless it declares its own list.
+func defaultSiteConfig() siteConfig {
+	return siteConfig{
+		Taxonomies: []taxonomyConfig{
+			{Name: "tags", Singular: "tag", Title: "Tags", Label: "Tagged"},
+		},
+		Links: linksConfig{
+			External: externalLinksConfig{
+				CacheTTL:     7 * 24 * time.Hour,
+				Concurrency:  8,
+				HostInterval: time.Second,
+				Timeout:      15 * time.Second,
+			},
+		},
+	}
+}
+
+// loadSiteConfig reads the build configuration at path. Decoding is strict: a
+// misspelt key would otherwise leave its option silently at the default, which
+// is exactly the kind of quiet failure a config file exists to prevent.
+func loadSiteConfig(path string) (siteConfig, error) {
+	cfg := defaultSiteConfig()
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if err := yaml.UnmarshalStrict(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	// content/ and static/ sit beside site.yaml.
+	root := filepath.Dir(path)
+	pages := sitePageNames(filepath.Join(root, "content"), filepath.Join(root, "static"))
+	if err := validateTaxonomies(cfg.Taxonomies, pages); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := validateProseLint(cfg.Lint.Prose); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := validateReadability(cfg.Report.Readability); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Dates.ShowEdited && !cfg.Dates.FromGit {
+		return cfg, fmt.Errorf("%s: dates.show_edited needs dates.from_git, which is where the edit dates come from", path)
+	}
+	return cfg, nil
+}
+
+// validateTaxonomies rejects a taxonomy list whose output paths would collide:
+// a name that isn't already a slug, one used twice, one that shadows another
+// page's path or frontmatter key, one of the site's own pages, or an all-digit
+// one that would land on a year archive. Unset prose fields are filled from the
+// name.
+func validateTaxonomies(taxonomies []taxonomyConfig, pages map[string]string) error {
+	seen := make(map[string]bool, len(taxonomies))
+	for i := range taxonomies {
+		t := &taxonomies[i]
+		switch {
+		case t.Name == "" || slugifyTag(t.Name) != t.Name:
+			return fmt.Errorf("taxonomy name %q must be lowercase letters, digits and hyphens", t.Name)
+		case strings.Trim(t.Name, "0123456789") == "":
+			return fmt.Errorf("taxonomy name %q would collide with the year archives", t.Name)
+		case reservedTaxonomyNames[t.Name]:
+			return fmt.Errorf("taxonomy name %q is already used by another page", t.Name)
+		case pages[t.Name] != "":
+			return fmt.Errorf("taxonomy name %q would overwrite the page built from %s", t.Name, pages[t.Name])
+		case seen[t.Name]:
+			return fmt.Errorf("taxonomy %q is declared twice", t.Name)
+		}
+		seen[t.Name] = true
+
+		if t.Singular == "" {
+			t.Singular = t.Name
+		}
+		if t.Title == "" {
+			t.Title = strings.ToUpper(t.Name[:1]) + t.Name[1:]
+		}
+		if t.Label == "" {
+			t.Label = "Filed under"
+		}
+	}
+	return nil
+}
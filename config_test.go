@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLoadSiteConfigMissingFile checks that a tree without site.yaml builds
+// with the defaults rather than failing.
+func TestLoadSiteConfigMissingFile(t *testing.T) {
+	cfg, err := loadSiteConfig(filepath.Join(t.TempDir(), "site.yaml"))
+	if err != nil {
+		t.Fatalf("loadSiteConfig on a missing file: %v", err)
+	}
+	if cfg.Feed.FullContent {
+		t.Error("full-content feed should be off by default")
+	}
+	if cfg.Pagination.Archive != 0 || cfg.Pagination.Tags != 0 {
+		t.Errorf("listings should be unpaginated by default, got %+v", cfg.Pagination)
+	}
+}
+
+// TestLoadSiteConfig reads the options back out of a file.
+func TestLoadSiteConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := os.WriteFile(path, []byte("feed:\n  full_content: true\npagination:\n  archive: 10\n  tags: 5\narchives:\n  months: true\n"), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	cfg, err := loadSiteConfig(path)
+	if err != nil {
+		t.Fatalf("loadSiteConfig: %v", err)
+	}
+	if !cfg.Feed.FullContent {
+		t.Error("feed.full_content was not read")
+	}
+	if cfg.Pagination.Archive != 10 || cfg.Pagination.Tags != 5 {
+		t.Errorf("pagination = %+v, want archive 10 and tags 5", cfg.Pagination)
+	}
+	if !cfg.Archives.Months {
+		t.Error("archives.months was not read")
+	}
+}
+
+// TestLoadSiteConfigRejectsUnknownKeys pins the strict decode: a typo has to
+// fail loudly rather than leave the option at its default.
+func TestLoadSiteConfigRejectsUnknownKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := os.WriteFile(path, []byte("feed:\n  full_contnet: true\n"), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+
+	_, err := loadSiteConfig(path)
+	if err == nil {
+		t.Fatal("expected an error for an unknown key, got nil")
+	}
+	if !strings.Contains(err.Error(), "full_contnet") {
+		t.Errorf("error should name the unknown key, got %v", err)
+	}
+}
+
+// TestLoadSiteConfigTaxonomies checks a declared list replaces the default
+// tags-only one, and that unset prose fields are filled from the name.
+func TestLoadSiteConfigTaxonomies(t *testing.T) {
+	if got := defaultSiteConfig().Taxonomies; len(got) != 1 || got[0].Name != "tags" || got[0].Label != "Tagged" {
+		t.Errorf("default taxonomies = %+v, want tags alone", got)
+	}
+
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	config := "taxonomies:\n  - name: tags\n    singular: tag\n    title: Tags\n    label: Tagged\n  - name: stack\n    singular: technology\n  - name: categories\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	cfg, err := loadSiteConfig(path)
+	if err != nil {
+		t.Fatalf("loadSiteConfig: %v", err)
+	}
+	if len(cfg.Taxonomies) != 3 {
+		t.Fatalf("taxonomies = %+v, want three", cfg.Taxonomies)
+	}
+	want := taxonomyConfig{Name: "stack", Singular: "technology", Title: "Stack", Label: "Filed under"}
+	if cfg.Taxonomies[1] != want {
+		t.Errorf("stack = %+v, want %+v", cfg.Taxonomies[1], want)
+	}
+	if cfg.Taxonomies[2].Singular != "categories" {
+		t.Errorf("categories singular = %q, want the name", cfg.Taxonomies[2].Singular)
+	}
+}
+
+// TestLoadSiteConfigRejectsCollidingTaxonomies checks every name whose pages
+// would land on top of something else fails the build.
+func TestLoadSiteConfigRejectsCollidingTaxonomies(t *testing.T) {
+	tests := map[string]string{
+		"not a slug":  "  - name: Stack\n",
+		"empty":       "  - singular: thing\n",
+		"year":        "  - name: \"2023\"\n",
+		"reserved":    "  - name: posts\n",
+		"cards":       "  - name: cards\n",
+		"frontmatter": "  - name: title\n",
+		"twice":       "  - name: stack\n  - name: stack\n",
+	}
+	for name, list := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "site.yaml")
+			if err := os.WriteFile(path, []byte("taxonomies:\n"+list), 0644); err != nil {
+				t.Fatalf("writing config: %v", err)
+			}
+			if _, err := loadSiteConfig(path); err == nil {
+				t.Errorf("expected an error for\n%s", list)
+			}
+		})
+	}
+}
+
+// TestLoadSiteConfigRejectsSitePageNames checks a taxonomy can't take the name
+// of a page in content/ or static/ beside site.yaml, or of a static directory.
+func TestLoadSiteConfigRejectsSitePageNames(t *testing.T) {
+	root := writeBuild(t, map[string]string{
+		"content/about.md":        "---\ntitle: About\n---\n",
+		"content/2023-01-15-a.md": "",
+		"content/stack/go.md":     "", // a registry, not a page
+		"static/sports.html":      "",
+		"static/theme.css":        "",
+		"static/img/logo.png":     "",
+	})
+	path := filepath.Join(root, "site.yaml")
+	for name, want := range map[string]string{
+		"about": "about.md", "sports": "sports.html", "img": "img", "stack": "", "theme": "",
+	} {
+		if err := os.WriteFile(path, []byte("taxonomies:\n  - name: "+name+"\n"), 0644); err != nil {
+			t.Fatalf("writing config: %v", err)
+		}
+		_, err := loadSiteConfig(path)
+		switch {
+		case want == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", name, err)
+		case want != "" && (err == nil || !strings.Contains(err.Error(), want)):
+			t.Errorf("%s: error %v, want one naming %s", name, err, want)
+		}
+	}
+}
+
+// TestLoadSiteConfigExternalLinks reads durations as Go writes them, and keeps
+// the defaults for what the file leaves out.
+func TestLoadSiteConfigExternalLinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	config := "links:\n  external:\n    allow: [www.reddit.com]\n    cache_ttl: 48h\n    host_interval: 250ms\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	cfg, err := loadSiteConfig(path)
+	if err != nil {
+		t.Fatalf("loadSiteConfig: %v", err)
+	}
+	ext := cfg.Links.External
+	if len(ext.Allow) != 1 || ext.CacheTTL != 48*time.Hour || ext.HostInterval != 250*time.Millisecond {
+		t.Errorf("external = %+v", ext)
+	}
+	if ext.Concurrency != 8 || ext.Timeout != 15*time.Second {
+		t.Errorf("external = %+v, want the default concurrency and timeout kept", ext)
+	}
+}
+
+func TestLoadSiteConfigReadability(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	config := "report:\n  readability:\n    min_flesch: 50\n    max_passive_ratio: 0.1\n"
+	if err := os.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	cfg, err := loadSiteConfig(path)
+	if err != nil {
+		t.Fatalf("loadSiteConfig: %v", err)
+	}
+	if r := cfg.Report.Readability; r.MinFlesch != 50 || r.MaxPassiveRatio != 0.1 || r.MaxAvgSentenceWords != 0 {
+		t.Errorf("readability = %+v", r)
+	}
+
+	if err := os.WriteFile(path, []byte("report:\n  readability:\n    max_passive_ratio: 10\n"), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	if _, err := loadSiteConfig(path); err == nil {
+		t.Error("expected an error for a passive ratio over 1")
+	}
+}
+
+// TestLoadSiteConfigDates rejects show_edited without the git dates it shows.
+func TestLoadSiteConfigDates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "site.yaml")
+	if err := os.WriteFile(path, []byte("dates:\n  show_edited: true\n"), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	if _, err := loadSiteConfig(path); err == nil || !strings.Contains(err.Error(), "dates.from_git") {
+		t.Errorf("err = %v, want show_edited refused without from_git", err)
+	}
+
+	if err := os.WriteFile(path, []byte("dates:\n  from_git: true\n  show_edited: true\n"), 0644); err != nil {
+		t.Fatalf("writing config: %v", err)
+	}
+	cfg, err := loadSiteConfig(path)
+	if err != nil {
+		t.Fatalf("loadSiteConfig: %v", err)
+	}
+	if !cfg.Dates.FromGit || !cfg.Dates.ShowEdited {
+		t.Errorf("dates = %+v", cfg.Dates)
+	}
+}
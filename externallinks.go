@@ -0,0 +1,283 @@
+package main
+
+// The external links check. Every http and https URL the build links to off
+// the site is requested — HEAD first, GET when a server won't answer HEAD —
+// with a bounded number in flight, a minimum interval between requests to one
+// host, and a timeout on each. Results are cached on disk, so a second run
+// within the TTL requests nothing it already knows the answer to.
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// externalLinkCachePath is the on-disk result cache, relative to the working
+// directory like site.yaml. It is a var so tests can point it elsewhere.
+var externalLinkCachePath = filepath.Join(".cache", "external-links.json")
+
+// externalLinkUserAgent identifies the checker; some hosts refuse Go's default.
+const externalLinkUserAgent = "simplessg-linkcheck/1 (+" + siteURL + ")"
+
+// linkResult is the outcome of requesting one URL.
+type linkResult struct {
+	Status  int       `json:"status,omitempty"` // final HTTP status, after redirects
+	Error   string    `json:"error,omitempty"`  // why there is no status
+	Checked time.Time `json:"checked"`
+}
+
+// ok reports whether the URL answered.
+func (r linkResult) ok() bool {
+	return r.Error == "" && r.Status < 400
+}
+
+// cacheable reports whether the result says something about the URL rather
+// than the moment: a success, or a page that is plainly gone. Timeouts, rate
+// limits and server errors are asked again next run.
+func (r linkResult) cacheable() bool {
+	return r.ok() || r.Status == http.StatusNotFound || r.Status == http.StatusGone
+}
+
+// linkCache maps URLs to their last cacheable result.
+type linkCache map[string]linkResult
+
+// loadLinkCache reads the cache at path. A missing or unreadable cache is an
+// empty one: the cost is only requests.
+func loadLinkCache(path string) linkCache {
+	cache := make(linkCache)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cache
+	}
+	if err := json.Unmarshal(data, &cache); err != nil {
+		return make(linkCache)
+	}
+	return cache
+}
+
+// save writes the cache to path, dropping entries older than ttl so it
+// doesn't grow without bound as links come and go.
+func (c linkCache) save(path string, ttl time.Duration, now time.Time) error {
+	for u, r := range c {
+		if now.Sub(r.Checked) > ttl {
+			delete(c, u)
+		}
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// hostLimiter spaces requests to each host at least interval apart.
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: make(map[string]time.Time)}
+}
+
+// wait blocks until a request to host may go, and books the slot after it.
+func (l *hostLimiter) wait(host string) {
+	l.mu.Lock()
+	now := time.Now()
+	slot := l.next[host]
+	if slot.Before(now) {
+		slot = now
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+	time.Sleep(slot.Sub(now))
+}
+
+// externalAllowed reports whether host is on the allowlist, itself or as a
+// subdomain of an entry.
+func externalAllowed(host string, allow []string) bool {
+	host = strings.ToLower(host)
+	for _, a := range allow {
+		a = strings.ToLower(strings.TrimPrefix(a, "*."))
+		if host == a || strings.HasSuffix(host, "."+a) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestLink asks for u, HEAD first. A server that refuses HEAD — with 405,
+// 501, or the 403 some send to anything unusual — is asked again with GET, of
+// which only the status is read.
+func requestLink(client *http.Client, u string) linkResult {
+	result := linkResult{Checked: time.Now()}
+	for _, method := range []string{http.MethodHead, http.MethodGet} {
+		req, err := http.NewRequest(method, u, nil)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		req.Header.Set("User-Agent", externalLinkUserAgent)
+		resp, err := client.Do(req)
+		if err != nil {
+			result.Error = err.Error()
+			return result
+		}
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+		result.Status = resp.StatusCode
+		if method == http.MethodHead && (resp.StatusCode == http.StatusMethodNotAllowed ||
+			resp.StatusCode == http.StatusNotImplemented || resp.StatusCode == http.StatusForbidden) {
+			continue
+		}
+		break
+	}
+	return result
+}
+
+// interleaveByHost reorders urls to take one from each host in turn, so the
+// workers spread across hosts rather than queueing behind one host's interval.
+func interleaveByHost(urls []string) []string {
+	var hosts []string
+	byHost := make(map[string][]string)
+	for _, u := range urls {
+		host := u
+		if parsed, err := url.Parse(u); err == nil {
+			host = strings.ToLower(parsed.Host)
+		}
+		if _, seen := byHost[host]; !seen {
+			hosts = append(hosts, host)
+		}
+		byHost[host] = append(byHost[host], u)
+	}
+
+	out := make([]string, 0, len(urls))
+	for len(out) < len(urls) {
+		for _, host := range hosts {
+			if queued := byHost[host]; len(queued) > 0 {
+				out = append(out, queued[0])
+				byHost[host] = queued[1:]
+			}
+		}
+	}
+	return out
+}
+
+// externalLinks collects every outbound http(s) URL in the build, without its
+// fragment, with where each is linked from.
+func externalLinks(pages map[string]*builtPage, allow []string) map[string][]finding {
+	links := make(map[string][]finding)
+	for _, from := range sortedPagePaths(pages) {
+		page := pages[from]
+		for _, link := range pageLinks(page) {
+			_, _, internal, err := internalTarget(from, link.URL)
+			if err != nil || internal {
+				continue // malformed links are the links check's to report
+			}
+			u, err := url.Parse(link.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || externalAllowed(u.Hostname(), allow) {
+				continue
+			}
+			u.Fragment = ""
+			links[u.String()] = append(links[u.String()], finding{File: page.File, Line: link.Line})
+		}
+	}
+	return links
+}
+
+// checkExternalLinks requests every outbound URL not answered in the cache
+// within the TTL, and reports each place a failing one is linked from.
+func checkExternalLinks(ctx checkContext) ([]finding, error) {
+	cfg := ctx.cfg.Links.External
+	pages, err := readBuiltPages(ctx.buildDir)
+	if err != nil {
+		return nil, err
+	}
+	links := externalLinks(pages, cfg.Allow)
+
+	now := time.Now()
+	cache := loadLinkCache(externalLinkCachePath)
+	results := make(map[string]linkResult, len(links))
+	urls := make([]string, 0, len(links))
+	for u := range links {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+
+	var todo []string
+	for _, u := range urls {
+		if r, ok := cache[u]; ok && now.Sub(r.Checked) <= cfg.CacheTTL {
+			results[u] = r
+			continue
+		}
+		todo = append(todo, u)
+	}
+
+	client := &http.Client{Timeout: cfg.Timeout}
+	limiter := newHostLimiter(cfg.HostInterval)
+	workers := cfg.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	queue := make(chan string)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for u := range queue {
+				parsed, _ := url.Parse(u)
+				limiter.wait(strings.ToLower(parsed.Host))
+				r := requestLink(client, u)
+				mu.Lock()
+				results[u] = r
+				if r.cacheable() {
+					cache[u] = r
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, u := range interleaveByHost(todo) {
+		queue <- u
+	}
+	close(queue)
+	wg.Wait()
+
+	fmt.Fprintf(ctx.stdout, "Checked %d external %s: %d requested, %d from cache\n",
+		len(links), plural(len(links), "link", "links"), len(todo), len(links)-len(todo))
+	if err := cache.save(externalLinkCachePath, cfg.CacheTTL, now); err != nil {
+		return nil, fmt.Errorf("saving link cache: %w", err)
+	}
+
+	var findings []finding
+	for _, u := range urls {
+		r := results[u]
+		if r.ok() {
+			continue
+		}
+		reason := r.Error
+		if reason == "" {
+			reason = fmt.Sprintf("%d %s", r.Status, http.StatusText(r.Status))
+		}
+		for _, f := range links[u] {
+			f.Message = fmt.Sprintf("external link %q: %s", u, reason)
+			findings = append(findings, f)
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// linkServer stands in for the outside world, counting the requests each path
+// receives.
+func linkServer(t *testing.T) (*httptest.Server, map[string]int, *sync.Mutex) {
+	t.Helper()
+	hits := make(map[string]int)
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		hits[r.Method+" "+r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/ok":
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		case "/busy":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hits, &mu
+}
+
+func TestCheckExternalLinks(t *testing.T) {
+	srv, hits, mu := linkServer(t)
+	original := externalLinkCachePath
+	externalLinkCachePath = filepath.Join(t.TempDir(), "cache.json")
+	defer func() { externalLinkCachePath = original }()
+
+	buildDir := writeBuild(t, map[string]string{
+		"index.html": "<a href=\"" + srv.URL + "/ok#section\">ok</a>\n" +
+			"<a href=\"" + srv.URL + "/moved\">moved</a> <a href=\"" + srv.URL + "/no-head\">no head</a>\n" +
+			"<a href=\"" + srv.URL + "/gone\">gone</a>\n" +
+			"<a href=\"" + srv.URL + "/slow\">slow</a> <a href=\"" + srv.URL + "/busy\">busy</a>\n" +
+			"<a href=\"https://flaky.example.com/x\">flaky</a> <a href=\"/local.html\">local</a> <a href=\"mailto:a@b.c\">mail</a>",
+		"posts.html": "<a href=\"" + srv.URL + "/ok\">ok again</a>",
+	})
+	cfg := defaultSiteConfig()
+	cfg.Links.External.Allow = []string{"example.com"}
+	cfg.Links.External.HostInterval = 0
+	cfg.Links.External.Timeout = 100 * time.Millisecond
+	var out bytes.Buffer
+	ctx := checkContext{buildDir: buildDir, cfg: cfg, stdout: &out}
+
+	findings, err := checkExternalLinks(ctx)
+	if err != nil {
+		t.Fatalf("checkExternalLinks: %v", err)
+	}
+	sortFindings(findings)
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.String())
+	}
+	report := strings.Join(got, "\n")
+	index := filepath.Join(buildDir, "index.html")
+	assertContains(t, report,
+		index+`:3: external link "`+srv.URL+`/gone": 404 Not Found`,
+		index+`:4: external link "`+srv.URL+`/slow": `,
+		index+`:4: external link "`+srv.URL+`/busy": 503 Service Unavailable`,
+	)
+	if len(findings) != 3 {
+		t.Errorf("findings:\n%s\nwant gone, slow and busy only", report)
+	}
+	if got := out.String(); got != "Checked 6 external links: 6 requested, 0 from cache\n" {
+		t.Errorf("summary = %q", got)
+	}
+
+	mu.Lock()
+	if hits["HEAD /ok"] != 2 { // once for /ok, once at the end of /moved's redirect
+		t.Errorf("HEAD /ok requested %d times, want 2: the same URL on two pages is requested once", hits["HEAD /ok"])
+	}
+	if hits["GET /no-head"] != 1 {
+		t.Errorf("GET /no-head requested %d times, want a GET after the refused HEAD", hits["GET /no-head"])
+	}
+	mu.Unlock()
+
+	// A second run asks again only about what the cache can't answer: the
+	// timeout and the 503.
+	for k := range hits {
+		delete(hits, k)
+	}
+	if _, err := checkExternalLinks(ctx); err != nil {
+		t.Fatalf("second checkExternalLinks: %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for path := range hits {
+		if path != "HEAD /slow" && path != "HEAD /busy" {
+			t.Errorf("%s was requested again despite a fresh cache entry", path)
+		}
+	}
+	if hits["HEAD /busy"] != 1 {
+		t.Error("a 503 should not be cached")
+	}
+}
+
+func TestHostLimiter(t *testing.T) {
+	limiter := newHostLimiter(30 * time.Millisecond)
+	start := time.Now()
+	limiter.wait("a.example")
+	limiter.wait("b.example")
+	if elapsed := time.Since(start); elapsed > 25*time.Millisecond {
+		t.Errorf("first requests to two hosts took %v, want no wait", elapsed)
+	}
+	limiter.wait("a.example")
+	limiter.wait("a.example")
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("three requests to one host took %v, want at least two intervals", elapsed)
+	}
+}
+
+func TestInterleaveByHost(t *testing.T) {
+	got := interleaveByHost([]string{
+		"https://a.example/1", "https://a.example/2", "https://a.example/3",
+		"https://b.example/1", "https://c.example/1", "https://c.example/2",
+	})
+	want := "a1 b1 c1 a2 c2 a3"
+	var short []string
+	for _, u := range got {
+		short = append(short, strings.TrimPrefix(strings.Replace(u, ".example/", "", 1), "https://"))
+	}
+	if strings.Join(short, " ") != want {
+		t.Errorf("order = %v, want %s", short, want)
+	}
+}
+
+func TestExternalAllowed(t *testing.T) {
+	allow := []string{"reddit.com", "*.medium.com"}
+	for host, want := range map[string]bool{
+		"reddit.com": true, "www.reddit.com": true, "WWW.Reddit.com": true,
+		"notreddit.com": false, "alice.medium.com": true, "github.com": false,
+	} {
+		if got := externalAllowed(host, allow); got != want {
+			t.Errorf("externalAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
@@ -1,26 +1,42 @@
 package main
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"html"
+	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
 	"time"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	mdhtml "github.com/gomarkdown/markdown/html"
 )
 
 // ── RSS ───────────────────────────────────────────────────────────────────
 //
 // A plain RSS 2.0 feed at /feed.xml, marshalled through encoding/xml so escaping
-// is the standard library's problem rather than ours. Items carry the post
-// summary rather than the full article: the rendered HTML contains root-relative
-// links that would resolve against the reader's own host, and rewriting them all
-// is more machinery than a summary feed is worth.
+// is the standard library's problem rather than ours. Items always carry the
+// post summary in <description>: the excerpt's HTML when the post marks one
+// with <!--more-->, and the one-line description otherwise. With
+// feed.full_content set in site.yaml they also carry the whole article in
+// content:encoded: the post is re-rendered for the feed, and every
+// root-relative link in it is made absolute, since a feed reader would
+// otherwise resolve /theme.css or /tags/aws.html against its own host.
+
+// contentNS is the RSS content module, which defines content:encoded.
+const contentNS = "http://purl.org/rss/1.0/modules/content/"
 
 type rssDocument struct {
-	XMLName xml.Name   `xml:"rss"`
-	Version string     `xml:"version,attr"`
-	AtomNS  string     `xml:"xmlns:atom,attr"`
-	Channel rssChannel `xml:"channel"`
+	XMLName   xml.Name   `xml:"rss"`
+	Version   string     `xml:"version,attr"`
+	AtomNS    string     `xml:"xmlns:atom,attr"`
+	ContentNS string     `xml:"xmlns:content,attr,omitempty"`
+	Channel   rssChannel `xml:"channel"`
 }
 
 type rssChannel struct {
@@ -41,12 +57,20 @@ type atomLink struct {
 }
 
 type rssItem struct {
-	Title       string   `xml:"title"`
-	Link        string   `xml:"link"`
-	GUID        rssGUID  `xml:"guid"`
-	PubDate     string   `xml:"pubDate"`
-	Description string   `xml:"description"`
-	Categories  []string `xml:"category,omitempty"`
+	Title       string        `xml:"title"`
+	Link        string        `xml:"link"`
+	GUID        rssGUID       `xml:"guid"`
+	PubDate     string        `xml:"pubDate"`
+	Description string        `xml:"description"`
+	Categories  []rssCategory `xml:"category,omitempty"`
+	Content     string        `xml:"content:encoded,omitempty"`
+}
+
+// rssCategory is one <category>. Tags carry no domain; a series carries the
+// series index as its domain, so readers can tell the two vocabularies apart.
+type rssCategory struct {
+	Domain string `xml:"domain,attr,omitempty"`
+	Value  string `xml:",chardata"`
 }
 
 type rssGUID struct {
@@ -54,41 +78,79 @@ type rssGUID struct {
 	Value       string `xml:",chardata"`
 }
 
-// buildFeed assembles the feed document from the dated posts, newest first.
-// lastBuildDate tracks the newest post rather than the wall clock, so rebuilding
-// an unchanged site produces an unchanged feed.
-func buildFeed(posts []*BlogPost) rssDocument {
+// feedChannel is the identity of one RSS feed: the site-wide feed and each
+// per-tag feed differ only in these and in which posts they carry.
+type feedChannel struct {
+	title       string
+	link        string // the HTML page the feed mirrors
+	description string
+	self        string // build-relative path of the feed itself
+}
+
+// siteFeedChannel describes the main feed at /feed.xml.
+var siteFeedChannel = feedChannel{
+	title:       siteName,
+	link:        siteURL + "/",
+	description: siteDescription,
+	self:        "feed.xml",
+}
+
+// buildFeed assembles the site-wide feed document from the dated posts, newest
+// first.
+func buildFeed(posts []*BlogPost, cfg feedConfig) rssDocument {
+	return buildRSS(posts, cfg, siteFeedChannel)
+}
+
+// buildRSS assembles one RSS document from the dated posts, newest first.
+// lastBuildDate is the newest post's last edit, or its date, rather than the
+// wall clock, so rebuilding an unchanged site produces an unchanged feed.
+func buildRSS(posts []*BlogPost, cfg feedConfig, channel feedChannel) rssDocument {
 	dated := datedPostsNewestFirst(posts)
 
 	items := make([]rssItem, 0, len(dated))
 	for _, post := range dated {
 		link := canonicalURL(post.OutputFile)
-		items = append(items, rssItem{
+		item := rssItem{
 			Title:       post.Title,
 			Link:        link,
 			GUID:        rssGUID{IsPermaLink: true, Value: link},
 			PubDate:     post.Date.Format(time.RFC1123Z),
-			Description: post.Description,
-			Categories:  post.Tags,
-		})
+			Description: feedSummary(post).Value,
+		}
+		for _, tag := range post.tags() {
+			item.Categories = append(item.Categories, rssCategory{Value: tag})
+		}
+		if post.Series != nil {
+			item.Categories = append(item.Categories, rssCategory{Domain: canonicalURL(seriesIndexPath), Value: post.Series.Name})
+		}
+		if cfg.FullContent {
+			item.Content = feedContentHTML(post)
+		}
+		items = append(items, item)
+	}
+
+	contentNamespace := ""
+	if cfg.FullContent {
+		contentNamespace = contentNS
 	}
 
 	lastBuild := ""
-	if len(dated) > 0 {
-		lastBuild = dated[0].Date.Format(time.RFC1123Z)
+	if newest := newestModified(dated); !newest.IsZero() {
+		lastBuild = newest.Format(time.RFC1123Z)
 	}
 
 	return rssDocument{
-		Version: "2.0",
-		AtomNS:  "http://www.w3.org/2005/Atom",
+		Version:   "2.0",
+		AtomNS:    "http://www.w3.org/2005/Atom",
+		ContentNS: contentNamespace,
 		Channel: rssChannel{
-			Title:         siteName,
-			Link:          siteURL + "/",
-			Description:   siteDescription,
+			Title:         channel.title,
+			Link:          channel.link,
+			Description:   channel.description,
 			Language:      "en-au",
 			LastBuildDate: lastBuild,
 			AtomLink: atomLink{
-				Href: canonicalURL("feed.xml"),
+				Href: canonicalURL(channel.self),
 				Rel:  "self",
 				Type: "application/rss+xml",
 			},
@@ -97,28 +159,356 @@ func buildFeed(posts []*BlogPost) rssDocument {
 	}
 }
 
-// generateFeed writes build/feed.xml. A site with no dated posts has nothing to
-// syndicate, so no file is written rather than an empty feed.
-func generateFeed(posts []*BlogPost, buildDir string) error {
-	feed := buildFeed(posts)
+// renderFeedAlternate renders the <link rel="alternate"> that advertises an RSS
+// feed from a page head, so browsers and readers can discover it.
+func renderFeedAlternate(channel feedChannel) string {
+	return fmt.Sprintf("<link rel=\"alternate\" type=\"application/rss+xml\" title=\"%s\" href=\"/%s\" />",
+		html.EscapeString(channel.title), html.EscapeString(channel.self))
+}
+
+// newestModified is the latest lastModified of posts, or the zero time when
+// there are none.
+func newestModified(posts []*BlogPost) time.Time {
+	var newest time.Time
+	for _, post := range posts {
+		if post.lastModified().After(newest) {
+			newest = post.lastModified()
+		}
+	}
+	return newest
+}
+
+// marshalRSS renders an RSS document with its XML declaration.
+func marshalRSS(feed rssDocument) ([]byte, error) {
+	body, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// feedFormats are the three documents generateFeed writes, in the order they
+// are advertised in the page head. Each is built from the same post list, so a
+// reader sees the same items whichever format it subscribes to.
+var feedFormats = []struct {
+	path, mediaType, title string
+}{
+	{"feed.xml", "application/rss+xml", siteName},
+	{"feed.atom", "application/atom+xml", siteName + " (Atom)"},
+	{"feed.json", "application/feed+json", siteName + " (JSON Feed)"},
+}
+
+// renderFeedFormatAlternates renders a <link rel="alternate"> for each of
+// feedFormats, for the template's {{feeds}}, so the page head advertises
+// exactly the feeds generateFeed writes.
+func renderFeedFormatAlternates() string {
+	links := make([]string, 0, len(feedFormats))
+	for _, format := range feedFormats {
+		links = append(links, fmt.Sprintf("<link rel=\"alternate\" type=\"%s\" title=\"%s\" href=\"/%s\" />",
+			format.mediaType, html.EscapeString(format.title), format.path))
+	}
+	return strings.Join(links, "\n    ")
+}
+
+// generateFeed writes build/feed.xml, build/feed.atom and build/feed.json. A
+// site with no dated posts has nothing to syndicate, so no file is written
+// rather than an empty feed.
+func generateFeed(posts []*BlogPost, buildDir string, cfg feedConfig) error {
+	feed := buildFeed(posts, cfg)
 	if len(feed.Channel.Items) == 0 {
 		return nil
 	}
 
-	body, err := xml.MarshalIndent(feed, "", "  ")
+	rss, err := marshalRSS(feed)
 	if err != nil {
 		return fmt.Errorf("marshalling feed: %w", err)
 	}
-
-	outputPath := filepath.Join(buildDir, "feed.xml")
-	if err := os.WriteFile(outputPath, append([]byte(xml.Header), body...), 0644); err != nil {
-		return fmt.Errorf("writing feed: %w", err)
+	atom, err := xml.MarshalIndent(buildAtomFeed(posts, cfg), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling atom feed: %w", err)
+	}
+	jsonFeed, err := json.MarshalIndent(buildJSONFeed(posts, cfg), "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling json feed: %w", err)
 	}
 
-	fmt.Printf("Generated feed: %s (%d items)\n", outputPath, len(feed.Channel.Items))
+	bodies := map[string][]byte{
+		"feed.xml":  rss,
+		"feed.atom": append([]byte(xml.Header), atom...),
+		"feed.json": append(jsonFeed, '\n'),
+	}
+	for _, format := range feedFormats {
+		outputPath := filepath.Join(buildDir, format.path)
+		if err := os.WriteFile(outputPath, bodies[format.path], 0644); err != nil {
+			return fmt.Errorf("writing %s: %w", format.path, err)
+		}
+		fmt.Printf("Generated feed: %s (%d items)\n", outputPath, len(feed.Channel.Items))
+	}
 	return nil
 }
 
+// feedContentHTML renders a post's body for content:encoded.
+func feedContentHTML(post *BlogPost) string {
+	return feedHTML(post.Markdown, canonicalURL(post.OutputFile))
+}
+
+// feedSummary is the summary a feed item carries: the excerpt as HTML when the
+// post marks one, otherwise the description as text. Atom needs to be told
+// which, so the type travels with the value.
+func feedSummary(post *BlogPost) atomContent {
+	if excerpt, ok := splitExcerpt(post.Markdown); ok {
+		return atomContent{Type: "html", Value: strings.TrimSpace(feedHTML(excerpt, canonicalURL(post.OutputFile)))}
+	}
+	return atomContent{Type: "text", Value: post.Description}
+}
+
+// feedHTML renders markdown for a feed reader. Code blocks come out as plain
+// <pre><code> rather than the site's line-numbered pre.code rows: those rows
+// lean on theme.css to lay out the gutter, and a feed reader that drops the
+// classes would run every line number into its code. Links are made absolute
+// against pageURL.
+func feedHTML(content []byte, pageURL string) string {
+	renderer := mdhtml.NewRenderer(mdhtml.RendererOptions{
+		Flags:          mdhtml.CommonFlags,
+		RenderNodeHook: feedCodeBlockHook,
+	})
+	return absoluteLinks(string(markdown.ToHTML(content, nil, renderer)), pageURL)
+}
+
+// feedCodeBlockHook renders a code block as escaped text inside <pre><code>,
+// keeping the language as a class for readers that highlight on their own.
+func feedCodeBlockHook(w io.Writer, node ast.Node, entering bool) (ast.WalkStatus, bool) {
+	cb, ok := node.(*ast.CodeBlock)
+	if !ok {
+		return ast.GoToNext, false
+	}
+	class := ""
+	if fields := strings.Fields(string(cb.Info)); len(fields) > 0 {
+		class = ` class="language-` + html.EscapeString(fields[0]) + `"`
+	}
+	io.WriteString(w, "<pre><code"+class+">"+html.EscapeString(string(cb.Literal))+"</code></pre>\n")
+	return ast.GoToNext, true
+}
+
+// linkAttrRe matches an href or src attribute whose value is root-relative or a
+// bare fragment. A protocol-relative "//host/path" is already absolute and is
+// left alone.
+var linkAttrRe = regexp.MustCompile(`(\s(?:href|src)=)(["'])((?:/(?:[^/"'][^"']*)?)|(?:#[^"']*))["']`)
+
+// absoluteLinks rewrites root-relative links in rendered HTML against siteURL,
+// and bare fragments against pageURL, so the markup means the same thing
+// wherever it is displayed.
+func absoluteLinks(body, pageURL string) string {
+	return linkAttrRe.ReplaceAllStringFunc(body, func(m string) string {
+		sub := linkAttrRe.FindStringSubmatch(m)
+		target := sub[3]
+		if strings.HasPrefix(target, "#") {
+			target = pageURL + target
+		} else {
+			target = siteURL + target
+		}
+		return sub[1] + sub[2] + target + sub[2]
+	})
+}
+
+// ── Atom ──────────────────────────────────────────────────────────────────
+//
+// An Atom 1.0 feed at /feed.atom. Entry IDs are the canonical post URLs, as the
+// RSS GUIDs are, so a reader that switches format doesn't see every post anew.
+
+const atomNS = "http://www.w3.org/2005/Atom"
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"feed"`
+	Xmlns    string      `xml:"xmlns,attr"`
+	Lang     string      `xml:"xml:lang,attr"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	ID       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Author   atomPerson  `xml:"author"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	ID         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    atomContent    `xml:"summary"`
+	Content    *atomContent   `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term   string `xml:"term,attr"`
+	Scheme string `xml:"scheme,attr,omitempty"`
+	Label  string `xml:"label,attr,omitempty"`
+}
+
+// buildAtomFeed assembles the Atom document. An entry's <updated> is its last
+// edit, or its date; like lastBuildDate in the RSS feed, the feed-level
+// <updated> is the newest of those, never the clock.
+func buildAtomFeed(posts []*BlogPost, cfg feedConfig) atomFeed {
+	dated := datedPostsNewestFirst(posts)
+
+	entries := make([]atomEntry, 0, len(dated))
+	for _, post := range dated {
+		link := canonicalURL(post.OutputFile)
+		entry := atomEntry{
+			Title:     post.Title,
+			ID:        link,
+			Link:      atomLink{Href: link, Rel: "alternate", Type: "text/html"},
+			Published: post.Date.Format(time.RFC3339),
+			Updated:   post.lastModified().Format(time.RFC3339),
+			Summary:   feedSummary(post),
+		}
+		for _, tag := range post.tags() {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		if post.Series != nil {
+			entry.Categories = append(entry.Categories, atomCategory{
+				Term:   post.Series.Slug,
+				Scheme: canonicalURL(seriesIndexPath),
+				Label:  post.Series.Name,
+			})
+		}
+		if cfg.FullContent {
+			entry.Content = &atomContent{Type: "html", Value: feedContentHTML(post)}
+		}
+		entries = append(entries, entry)
+	}
+
+	updated := ""
+	if newest := newestModified(dated); !newest.IsZero() {
+		updated = newest.Format(time.RFC3339)
+	}
+
+	return atomFeed{
+		Xmlns:    atomNS,
+		Lang:     "en-au",
+		Title:    siteName,
+		Subtitle: siteDescription,
+		ID:       siteURL + "/",
+		Updated:  updated,
+		Author:   atomPerson{Name: siteAuthor},
+		Links: []atomLink{
+			{Href: canonicalURL("feed.atom"), Rel: "self", Type: "application/atom+xml"},
+			{Href: siteURL + "/", Rel: "alternate", Type: "text/html"},
+		},
+		Entries: entries,
+	}
+}
+
+// ── JSON Feed ─────────────────────────────────────────────────────────────
+//
+// A JSON Feed 1.1 document at /feed.json. The spec has no feed-level date, so
+// determinism comes for free: every date in it belongs to a post.
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Description string         `json:"description"`
+	Language    string         `json:"language"`
+	Authors     []jsonAuthor   `json:"authors"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	ID            string          `json:"id"`
+	URL           string          `json:"url"`
+	Title         string          `json:"title"`
+	Summary       string          `json:"summary"`
+	ContentHTML   string          `json:"content_html,omitempty"`
+	ContentText   string          `json:"content_text,omitempty"`
+	DatePublished string          `json:"date_published"`
+	DateModified  string          `json:"date_modified,omitempty"`
+	Tags          []string        `json:"tags,omitempty"`
+	Series        *jsonFeedSeries `json:"_series,omitempty"`
+}
+
+// jsonFeedSeries is a JSON Feed extension — the spec reserves keys starting
+// with an underscore for them — placing an item in its series.
+type jsonFeedSeries struct {
+	About string `json:"about"`
+	Name  string `json:"name"`
+	URL   string `json:"url"`
+	Part  int    `json:"part"`
+	Parts int    `json:"parts"`
+}
+
+// buildJSONFeed assembles the JSON Feed document. The spec requires every item
+// to carry content_html or content_text, so a summary-only feed carries the
+// summary RSS and Atom do: the excerpt as content_html when the post marks
+// one, the description as content_text otherwise. summary is plain text in the
+// spec, so it is always the description.
+func buildJSONFeed(posts []*BlogPost, cfg feedConfig) jsonFeed {
+	dated := datedPostsNewestFirst(posts)
+
+	items := make([]jsonFeedItem, 0, len(dated))
+	for _, post := range dated {
+		link := canonicalURL(post.OutputFile)
+		item := jsonFeedItem{
+			ID:            link,
+			URL:           link,
+			Title:         post.Title,
+			Summary:       post.Description,
+			DatePublished: post.Date.Format(time.RFC3339),
+			Tags:          post.tags(),
+		}
+		if !post.Modified.IsZero() {
+			item.DateModified = post.Modified.Format(time.RFC3339)
+		}
+		switch summary := feedSummary(post); {
+		case cfg.FullContent:
+			item.ContentHTML = feedContentHTML(post)
+		case summary.Type == "html":
+			item.ContentHTML = summary.Value
+		default:
+			item.ContentText = summary.Value
+		}
+		if post.Series != nil {
+			item.Series = &jsonFeedSeries{
+				About: canonicalURL(seriesIndexPath),
+				Name:  post.Series.Name,
+				URL:   siteURL + seriesURL(post.Series),
+				Part:  post.Series.part(post),
+				Parts: len(post.Series.Parts),
+			}
+		}
+		items = append(items, item)
+	}
+
+	return jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       siteName,
+		HomePageURL: siteURL + "/",
+		FeedURL:     canonicalURL("feed.json"),
+		Description: siteDescription,
+		Language:    siteLanguage,
+		Authors:     []jsonAuthor{{Name: siteAuthor, URL: canonicalURL("about.html")}},
+		Items:       items,
+	}
+}
+
 // ── Sitemap ───────────────────────────────────────────────────────────────
 
 type sitemapURLSet struct {
@@ -133,22 +523,26 @@ type sitemapURL struct {
 }
 
 // buildSitemap lists the site root, every generated page passed in, and every
-// post. Posts carry a lastmod taken from their date; listing and standalone
-// pages have no meaningful modification date to report, so they carry none
-// rather than a date invented at build time.
-func buildSitemap(posts []*BlogPost, pages []string) sitemapURLSet {
+// post. Posts carry a lastmod taken from their last edit, or else their date;
+// a page carries one only where pageDates has it — from git history, with
+// dates.from_git — rather than a date invented at build time.
+func buildSitemap(posts []*BlogPost, pages []string, pageDates map[string]time.Time) sitemapURLSet {
 	set := sitemapURLSet{Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9"}
 
 	for _, page := range pages {
 		// index.html canonicalises to the bare root, which is already the
 		// first entry for every site with content.
-		set.URLs = append(set.URLs, sitemapURL{Loc: canonicalURL(page)})
+		entry := sitemapURL{Loc: canonicalURL(page)}
+		if date, ok := pageDates[page]; ok {
+			entry.LastMod = date.UTC().Format("2006-01-02")
+		}
+		set.URLs = append(set.URLs, entry)
 	}
 
 	for _, post := range posts {
 		entry := sitemapURL{Loc: canonicalURL(post.OutputFile)}
-		if !post.Date.IsZero() {
-			entry.LastMod = post.Date.Format("2006-01-02")
+		if modified := post.lastModified(); !modified.IsZero() {
+			entry.LastMod = modified.UTC().Format("2006-01-02")
 		}
 		set.URLs = append(set.URLs, entry)
 	}
@@ -157,8 +551,8 @@ func buildSitemap(posts []*BlogPost, pages []string) sitemapURLSet {
 }
 
 // generateSitemap writes build/sitemap.xml.
-func generateSitemap(posts []*BlogPost, pages []string, buildDir string) error {
-	set := buildSitemap(posts, pages)
+func generateSitemap(posts []*BlogPost, pages []string, pageDates map[string]time.Time, buildDir string) error {
+	set := buildSitemap(posts, pages, pageDates)
 	if len(set.URLs) == 0 {
 		return nil
 	}
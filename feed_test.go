@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"encoding/xml"
 	"os"
 	"path/filepath"
@@ -26,7 +27,7 @@ func feedTestPosts(t *testing.T) []*BlogPost {
 			Date:        testDate(t, "2023-01-15"),
 			OutputFile:  "2023-01-15-first-post.html",
 			Description: "The first one.",
-			Tags:        []string{"aws", "devops"},
+			Terms:       map[string][]string{"tags": {"aws", "devops"}},
 		},
 		{
 			Title:       "Second Post",
@@ -41,7 +42,7 @@ func feedTestPosts(t *testing.T) []*BlogPost {
 
 // TestBuildFeed checks ordering, canonical links, and the channel metadata.
 func TestBuildFeed(t *testing.T) {
-	feed := buildFeed(feedTestPosts(t))
+	feed := buildFeed(feedTestPosts(t), feedConfig{})
 
 	if len(feed.Channel.Items) != 2 {
 		t.Fatalf("expected 2 items (undated pages excluded), got %d", len(feed.Channel.Items))
@@ -63,7 +64,7 @@ func TestBuildFeed(t *testing.T) {
 	if _, err := time.Parse(time.RFC1123Z, item.PubDate); err != nil {
 		t.Errorf("pubDate %q is not RFC1123Z: %v", item.PubDate, err)
 	}
-	if len(item.Categories) != 2 || item.Categories[0] != "aws" {
+	if len(item.Categories) != 2 || item.Categories[0].Value != "aws" {
 		t.Errorf("categories = %v, want the post's tags", item.Categories)
 	}
 
@@ -91,7 +92,7 @@ func TestGenerateFeed(t *testing.T) {
 		Description: `He said "LGTM" & meant it`,
 	}}
 
-	if err := generateFeed(posts, buildDir); err != nil {
+	if err := generateFeed(posts, buildDir, feedConfig{}); err != nil {
 		t.Fatalf("generateFeed: %v", err)
 	}
 
@@ -128,22 +129,24 @@ func TestGenerateFeed(t *testing.T) {
 }
 
 // TestGenerateFeedNoPosts confirms an empty site writes no feed rather than an
-// empty one.
+// empty one, in any format.
 func TestGenerateFeedNoPosts(t *testing.T) {
 	buildDir := t.TempDir()
 
-	if err := generateFeed([]*BlogPost{{Title: "About", OutputFile: "about.html"}}, buildDir); err != nil {
+	if err := generateFeed([]*BlogPost{{Title: "About", OutputFile: "about.html"}}, buildDir, feedConfig{}); err != nil {
 		t.Fatalf("generateFeed: %v", err)
 	}
-	if _, err := os.Stat(filepath.Join(buildDir, "feed.xml")); !os.IsNotExist(err) {
-		t.Error("feed.xml should not be written when there is nothing to syndicate")
+	for _, format := range feedFormats {
+		if _, err := os.Stat(filepath.Join(buildDir, format.path)); !os.IsNotExist(err) {
+			t.Errorf("%s should not be written when there is nothing to syndicate", format.path)
+		}
 	}
 }
 
 // TestBuildSitemap checks that pages and posts are both listed, and that only
 // dated posts claim a lastmod.
 func TestBuildSitemap(t *testing.T) {
-	set := buildSitemap(feedTestPosts(t), []string{"index.html", "posts.html", "tags/aws.html"})
+	set := buildSitemap(feedTestPosts(t), []string{"index.html", "posts.html", "tags/aws.html"}, nil)
 
 	if set.Xmlns != "http://www.sitemaps.org/schemas/sitemap/0.9" {
 		t.Errorf("unexpected namespace %q", set.Xmlns)
@@ -174,11 +177,61 @@ func TestBuildSitemap(t *testing.T) {
 	}
 }
 
+// TestFeedUpdatedModified checks that an edit to an older post moves both the
+// RSS lastBuildDate and the Atom <updated> to the edit.
+func TestFeedUpdatedModified(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].Modified = testDate(t, "2024-05-01")
+
+	if got, want := buildFeed(posts, feedConfig{}).Channel.LastBuildDate, posts[0].Modified.Format(time.RFC1123Z); got != want {
+		t.Errorf("lastBuildDate = %q, want the edit %q", got, want)
+	}
+	if got, want := buildAtomFeed(posts, feedConfig{}).Updated, posts[0].Modified.Format(time.RFC3339); got != want {
+		t.Errorf("atom updated = %q, want the edit %q", got, want)
+	}
+}
+
+// TestRenderFeedFormatAlternates checks the page head advertises every feed
+// generateFeed writes, and nothing else.
+func TestRenderFeedFormatAlternates(t *testing.T) {
+	got := renderFeedFormatAlternates()
+	assertContains(t, got,
+		`<link rel="alternate" type="application/rss+xml" title="LetsBuild.cloud" href="/feed.xml" />`,
+		`<link rel="alternate" type="application/atom+xml" title="LetsBuild.cloud (Atom)" href="/feed.atom" />`,
+		`<link rel="alternate" type="application/feed+json" title="LetsBuild.cloud (JSON Feed)" href="/feed.json" />`)
+	if n := strings.Count(got, "<link"); n != len(feedFormats) {
+		t.Errorf("%d links, want %d", n, len(feedFormats))
+	}
+}
+
+// TestBuildSitemapModified checks an edited post's lastmod is its last edit,
+// and a page's is whatever pageDates gives it.
+func TestBuildSitemapModified(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].Modified = testDate(t, "2024-05-01")
+	pageDates := map[string]time.Time{"posts.html": testDate(t, "2024-05-01")}
+	set := buildSitemap(posts, []string{"posts.html", "tags/aws.html"}, pageDates)
+
+	byLoc := make(map[string]string, len(set.URLs))
+	for _, u := range set.URLs {
+		byLoc[u.Loc] = u.LastMod
+	}
+	if got := byLoc[canonicalURL(posts[0].OutputFile)]; got != "2024-05-01" {
+		t.Errorf("edited post lastmod = %q, want 2024-05-01", got)
+	}
+	if got := byLoc[canonicalURL("posts.html")]; got != "2024-05-01" {
+		t.Errorf("posts.html lastmod = %q, want 2024-05-01", got)
+	}
+	if got := byLoc[canonicalURL("tags/aws.html")]; got != "" {
+		t.Errorf("undated page lastmod = %q, want none", got)
+	}
+}
+
 // TestGenerateSitemap confirms the written document parses.
 func TestGenerateSitemap(t *testing.T) {
 	buildDir := t.TempDir()
 
-	if err := generateSitemap(feedTestPosts(t), []string{"index.html"}, buildDir); err != nil {
+	if err := generateSitemap(feedTestPosts(t), []string{"index.html"}, nil, buildDir); err != nil {
 		t.Fatalf("generateSitemap: %v", err)
 	}
 
@@ -240,21 +293,25 @@ func TestGeneratedOutputIsStableAcrossRebuilds(t *testing.T) {
 
 	build := func() (string, string) {
 		dir := t.TempDir()
-		if err := generateFeed(posts, dir); err != nil {
+		if err := generateFeed(posts, dir, feedConfig{}); err != nil {
 			t.Fatalf("generateFeed: %v", err)
 		}
-		if err := generateSitemap(posts, staticPages, dir); err != nil {
+		if err := generateSitemap(posts, staticPages, nil, dir); err != nil {
 			t.Fatalf("generateSitemap: %v", err)
 		}
-		feed, err := os.ReadFile(filepath.Join(dir, "feed.xml"))
-		if err != nil {
-			t.Fatalf("reading feed.xml: %v", err)
+		var feeds strings.Builder
+		for _, format := range feedFormats {
+			feed, err := os.ReadFile(filepath.Join(dir, format.path))
+			if err != nil {
+				t.Fatalf("reading %s: %v", format.path, err)
+			}
+			feeds.Write(feed)
 		}
 		sitemap, err := os.ReadFile(filepath.Join(dir, "sitemap.xml"))
 		if err != nil {
 			t.Fatalf("reading sitemap.xml: %v", err)
 		}
-		return string(feed), string(sitemap)
+		return feeds.String(), string(sitemap)
 	}
 
 	firstFeed, firstSitemap := build()
@@ -262,7 +319,7 @@ func TestGeneratedOutputIsStableAcrossRebuilds(t *testing.T) {
 	secondFeed, secondSitemap := build()
 
 	if firstFeed != secondFeed {
-		t.Error("feed.xml changed between two builds of identical content")
+		t.Error("a feed changed between two builds of identical content")
 	}
 	if firstSitemap != secondSitemap {
 		t.Error("sitemap.xml changed between two builds of identical content")
@@ -274,7 +331,7 @@ func TestGeneratedOutputIsStableAcrossRebuilds(t *testing.T) {
 func TestGenerateSitemapNoURLs(t *testing.T) {
 	dir := t.TempDir()
 
-	if err := generateSitemap(nil, nil, dir); err != nil {
+	if err := generateSitemap(nil, nil, nil, dir); err != nil {
 		t.Fatalf("generateSitemap: %v", err)
 	}
 	if _, err := os.Stat(filepath.Join(dir, "sitemap.xml")); !os.IsNotExist(err) {
@@ -295,7 +352,7 @@ func TestGenerateFeedAtomNamespace(t *testing.T) {
 		Date:        time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
 	}}
 
-	if err := generateFeed(posts, dir); err != nil {
+	if err := generateFeed(posts, dir, feedConfig{}); err != nil {
 		t.Fatalf("generateFeed: %v", err)
 	}
 	raw, err := os.ReadFile(filepath.Join(dir, "feed.xml"))
@@ -313,3 +370,269 @@ func TestGenerateFeedAtomNamespace(t *testing.T) {
 		}
 	}
 }
+
+// TestBuildFeedSummaryOnlyByDefault keeps full content opt-in: without the
+// switch, items carry no content:encoded and the document declares no content
+// namespace.
+func TestBuildFeedSummaryOnlyByDefault(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].Markdown = []byte("Body text.")
+
+	feed := buildFeed(posts, feedConfig{})
+	if feed.ContentNS != "" {
+		t.Errorf("content namespace declared without full content: %q", feed.ContentNS)
+	}
+	for _, item := range feed.Channel.Items {
+		if item.Content != "" {
+			t.Errorf("item %q carries content without full content enabled", item.Title)
+		}
+	}
+}
+
+// TestBuildFeedFullContent checks the rendered body reaches content:encoded
+// with every root-relative link made absolute, and that code blocks are plain
+// <pre><code> rather than the theme's line-numbered rows.
+func TestBuildFeedFullContent(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].Markdown = []byte("See [the tags](/tags.html) and ![a diagram](/img/flow.png).\n\n" +
+		"Jump to [the end](#end), or read [elsewhere](https://example.com/x).\n\n" +
+		"```go\nfmt.Println(\"<hi>\")\n```\n")
+
+	feed := buildFeed(posts, feedConfig{FullContent: true})
+	if feed.ContentNS != contentNS {
+		t.Errorf("content namespace = %q, want %q", feed.ContentNS, contentNS)
+	}
+
+	content := feed.Channel.Items[1].Content
+	assertContains(t, content,
+		`href="https://letsbuild.cloud/tags.html"`,
+		`src="https://letsbuild.cloud/img/flow.png"`,
+		`href="https://letsbuild.cloud/2023-01-15-first-post.html#end"`,
+		`href="https://example.com/x"`,
+		`<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)`,
+	)
+	assertNotContains(t, content, `href="/`, `src="/`, `class="cl"`, `class="ln"`, `t-fn`)
+
+	// The summary is unchanged alongside the full text.
+	if feed.Channel.Items[1].Description != "The first one." {
+		t.Errorf("description = %q, want the summary", feed.Channel.Items[1].Description)
+	}
+}
+
+// TestAbsoluteLinks covers the rewriting rules on their own, including the
+// links it must leave alone.
+func TestAbsoluteLinks(t *testing.T) {
+	page := "https://letsbuild.cloud/post.html"
+	tests := []struct{ in, want string }{
+		{`<a href="/">home</a>`, `<a href="https://letsbuild.cloud/">home</a>`},
+		{`<a href="/posts.html">`, `<a href="https://letsbuild.cloud/posts.html">`},
+		{`<img src='/a.png'>`, `<img src='https://letsbuild.cloud/a.png'>`},
+		{`<a href="#top">`, `<a href="https://letsbuild.cloud/post.html#top">`},
+		{`<a href="//cdn.example.com/x.js">`, `<a href="//cdn.example.com/x.js">`},
+		{`<a href="https://example.com/">`, `<a href="https://example.com/">`},
+		{`<a href="relative.html">`, `<a href="relative.html">`},
+		{`<p>href="/not-an-attribute"</p>`, `<p>href="/not-an-attribute"</p>`},
+	}
+	for _, tt := range tests {
+		if got := absoluteLinks(tt.in, page); got != tt.want {
+			t.Errorf("absoluteLinks(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestGenerateFeedFullContentRoundTrips reads content:encoded back through a
+// parser: the HTML is escaped into the XML, and has to come out intact.
+func TestGenerateFeedFullContentRoundTrips(t *testing.T) {
+	dir := t.TempDir()
+	posts := []*BlogPost{{
+		Title:       "A Post",
+		OutputFile:  "2024-01-02-a-post.html",
+		Description: "Something happened.",
+		Date:        time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC),
+		Markdown:    []byte("Tom & Jerry read [this](/about.html)."),
+	}}
+
+	if err := generateFeed(posts, dir, feedConfig{FullContent: true}); err != nil {
+		t.Fatalf("generateFeed: %v", err)
+	}
+	raw, err := os.ReadFile(filepath.Join(dir, "feed.xml"))
+	if err != nil {
+		t.Fatalf("reading feed.xml: %v", err)
+	}
+	if !strings.Contains(string(raw), `xmlns:content="`+contentNS+`"`) {
+		t.Errorf("feed.xml does not declare the content namespace\n%s", raw)
+	}
+
+	var parsed struct {
+		Items []struct {
+			Content string `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		} `xml:"channel>item"`
+	}
+	if err := xml.Unmarshal(raw, &parsed); err != nil {
+		t.Fatalf("generated feed does not parse: %v", err)
+	}
+	want := `<p>Tom &amp; Jerry read <a href="https://letsbuild.cloud/about.html">this</a>.</p>`
+	if len(parsed.Items) != 1 || !strings.Contains(parsed.Items[0].Content, want) {
+		t.Errorf("content:encoded round-tripped as %+v, want it to contain %q", parsed.Items, want)
+	}
+}
+
+// TestBuildAtomFeed checks the Atom document against the same post list as the
+// RSS feed: newest first, canonical IDs, and a feed-level date taken from the
+// newest post.
+func TestBuildAtomFeed(t *testing.T) {
+	feed := buildAtomFeed(feedTestPosts(t), feedConfig{})
+
+	if len(feed.Entries) != 2 {
+		t.Fatalf("expected 2 entries (undated pages excluded), got %d", len(feed.Entries))
+	}
+	if feed.Entries[0].Title != "Second Post" {
+		t.Errorf("expected newest post first, got %q", feed.Entries[0].Title)
+	}
+	entry := feed.Entries[1]
+	wantLink := "https://letsbuild.cloud/2023-01-15-first-post.html"
+	if entry.ID != wantLink || entry.Link.Href != wantLink {
+		t.Errorf("entry id/link = %q/%q, want %q", entry.ID, entry.Link.Href, wantLink)
+	}
+	if _, err := time.Parse(time.RFC3339, entry.Published); err != nil {
+		t.Errorf("published %q is not RFC3339: %v", entry.Published, err)
+	}
+	if len(entry.Categories) != 2 || entry.Categories[0].Term != "aws" {
+		t.Errorf("categories = %+v, want the post's tags", entry.Categories)
+	}
+	if entry.Content != nil {
+		t.Error("summary-only feed should carry no <content>")
+	}
+	if feed.Updated != feed.Entries[0].Updated {
+		t.Errorf("feed updated = %q, want newest entry %q", feed.Updated, feed.Entries[0].Updated)
+	}
+	if feed.Links[0].Href != "https://letsbuild.cloud/feed.atom" || feed.Links[0].Rel != "self" {
+		t.Errorf("first link = %+v, want the self link", feed.Links[0])
+	}
+}
+
+// TestBuildJSONFeed checks the JSON Feed items, including the content field the
+// spec requires whichever mode the feed is in.
+func TestBuildJSONFeed(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].Markdown = []byte("See [home](/).")
+
+	summary := buildJSONFeed(posts, feedConfig{})
+	if summary.Version != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("version = %q", summary.Version)
+	}
+	if len(summary.Items) != 2 || summary.Items[0].Title != "Second Post" {
+		t.Fatalf("items = %+v, want two, newest first", summary.Items)
+	}
+	if got := summary.Items[1].ContentText; got != "The first one." {
+		t.Errorf("summary feed content_text = %q, want the description", got)
+	}
+
+	full := buildJSONFeed(posts, feedConfig{FullContent: true})
+	if got := full.Items[1].ContentHTML; !strings.Contains(got, `href="https://letsbuild.cloud/"`) {
+		t.Errorf("full feed content_html = %q, want absolute links", got)
+	}
+	if full.Items[1].ContentText != "" {
+		t.Error("full feed should not also carry content_text")
+	}
+}
+
+// TestGenerateFeedWritesEveryFormat reads each format back through its parser
+// and checks they agree on the items.
+func TestGenerateFeedWritesEveryFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := generateFeed(feedTestPosts(t), dir, feedConfig{}); err != nil {
+		t.Fatalf("generateFeed: %v", err)
+	}
+
+	var atom struct {
+		Entries []struct {
+			Title string `xml:"title"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal([]byte(readFile(t, filepath.Join(dir, "feed.atom"))), &atom); err != nil {
+		t.Fatalf("feed.atom does not parse: %v", err)
+	}
+	var jf struct {
+		Items []struct {
+			Title string `json:"title"`
+		} `json:"items"`
+	}
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, "feed.json"))), &jf); err != nil {
+		t.Fatalf("feed.json does not parse: %v", err)
+	}
+
+	if len(atom.Entries) != 2 || len(jf.Items) != 2 {
+		t.Fatalf("atom has %d entries and json %d items, want 2 each", len(atom.Entries), len(jf.Items))
+	}
+	for i := range atom.Entries {
+		if atom.Entries[i].Title != jf.Items[i].Title {
+			t.Errorf("item %d: atom %q, json %q", i, atom.Entries[i].Title, jf.Items[i].Title)
+		}
+	}
+}
+
+// TestFeedSummaryUsesExcerpt checks a post's excerpt becomes the summary in
+// all three feeds as HTML, with links made absolute, and that other posts keep
+// their description as text.
+func TestFeedSummaryUsesExcerpt(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].Markdown = []byte("A teaser with [a link](/tags.html).\n\n<!--more-->\n\nThe rest.")
+
+	rss := buildFeed(posts, feedConfig{})
+	want := `<p>A teaser with <a href="https://letsbuild.cloud/tags.html">a link</a>.</p>`
+	if got := rss.Channel.Items[1].Description; got != want {
+		t.Errorf("rss description = %q, want %q", got, want)
+	}
+	if got := rss.Channel.Items[0].Description; got != "The second one." {
+		t.Errorf("unmarked post description = %q, want its description", got)
+	}
+
+	atom := buildAtomFeed(posts, feedConfig{})
+	if s := atom.Entries[1].Summary; s.Type != "html" || s.Value != want {
+		t.Errorf("atom summary = %+v, want html %q", s, want)
+	}
+	if s := atom.Entries[0].Summary; s.Type != "text" {
+		t.Errorf("unmarked atom summary type = %q, want text", s.Type)
+	}
+
+	jf := buildJSONFeed(posts, feedConfig{})
+	if item := jf.Items[1]; item.ContentHTML != want || item.ContentText != "" {
+		t.Errorf("json content_html = %q, content_text = %q; want the excerpt as html", item.ContentHTML, item.ContentText)
+	}
+	if item := jf.Items[0]; item.ContentText != "The second one." || item.ContentHTML != "" {
+		t.Errorf("unmarked json content_text = %q, content_html = %q; want its description", item.ContentText, item.ContentHTML)
+	}
+}
+
+// TestFeedsCarrySeriesMembership checks each format places a series part in
+// its series, distinct from its tags.
+func TestFeedsCarrySeriesMembership(t *testing.T) {
+	posts := feedTestPosts(t)
+	posts[0].SeriesName = "Pipelines"
+	linkSeries(posts)
+
+	rss := buildFeed(posts, feedConfig{})
+	cats := rss.Channel.Items[1].Categories
+	last := cats[len(cats)-1]
+	if last.Value != "Pipelines" || last.Domain != "https://letsbuild.cloud/series.html" {
+		t.Errorf("rss series category = %+v", last)
+	}
+	if rss.Channel.Items[0].Categories != nil {
+		t.Errorf("a post outside any series or tag got categories %+v", rss.Channel.Items[0].Categories)
+	}
+
+	atom := buildAtomFeed(posts, feedConfig{})
+	acats := atom.Entries[1].Categories
+	if a := acats[len(acats)-1]; a.Term != "pipelines" || a.Label != "Pipelines" || a.Scheme == "" {
+		t.Errorf("atom series category = %+v", a)
+	}
+
+	jf := buildJSONFeed(posts, feedConfig{})
+	if s := jf.Items[1].Series; s == nil || s.Part != 1 || s.Parts != 1 || s.URL != "https://letsbuild.cloud/series.html#pipelines" {
+		t.Errorf("json _series = %+v", s)
+	}
+	if jf.Items[0].Series != nil {
+		t.Error("a post outside any series got a _series object")
+	}
+}
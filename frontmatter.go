@@ -0,0 +1,346 @@
+package main
+
+// The frontmatter check. FrontMatter is decoded leniently: an unknown key is
+// kept in Params and never read, so `descripiton:` quietly ships a post with an
+// extracted description, and a tag line that can't be read holds no tags rather
+// than fail the build. This check reads each post's frontmatter against the
+// keys the generator knows and says what the build would have ignored, along
+// with titles and descriptions of a length search results cut or pad, and posts
+// that share a title, a slug or an output path.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v2"
+)
+
+// frontmatterKind is the shape a frontmatter key's value must take.
+type frontmatterKind int
+
+const (
+	kindText  frontmatterKind = iota // a scalar, read as a string
+	kindTerms                        // a string of space-separated terms, or a list of them
+	kindInt
+)
+
+// frontmatterSchema is every key the generator reads, by the shape it reads.
+// Each configured taxonomy adds its own name as terms.
+var frontmatterSchema = map[string]frontmatterKind{
+	"title":        kindText,
+	"description":  kindText,
+	"tags":         kindTerms,
+	"series":       kindText,
+	"series_order": kindInt,
+	"related":      kindTerms,
+	"not_related":  kindTerms,
+
+	// Carried over from the Astro site the older posts were written for.
+	// Nothing reads them, but they aren't typos either.
+	"layout":        kindText,
+	"date":          kindText,
+	"author":        kindText,
+	"canonicalurl":  kindText,
+	"canonicalsite": kindText,
+	"image":         kindText,
+}
+
+// Length ranges, in characters, outside which a title or description is
+// flagged. Search results cut titles at around 60 and descriptions at around
+// 160; a description much under 50 leaves the snippet to be made up from the
+// page instead.
+const (
+	minTitleLength       = 3
+	maxTitleLength       = 70
+	minDescriptionLength = 50
+	maxDescriptionLength = 160
+)
+
+// frontmatterKeyRe matches a top-level key at the start of a frontmatter line.
+var frontmatterKeyRe = regexp.MustCompile(`^([^\s#:'"][^:]*?|"[^"]*"|'[^']*')\s*:(\s|$)`)
+
+// splitFrontmatter returns the YAML between a file's opening --- fences and
+// the line it starts on, or ok false when the file has none.
+func splitFrontmatter(src string) (yamlText string, firstLine int, ok bool) {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return "", 0, false
+	}
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "---" {
+			return strings.Join(lines[1:i], "\n"), 2, true
+		}
+	}
+	return "", 0, false
+}
+
+// frontmatterKeyLines maps each top-level key to the file line it is on.
+func frontmatterKeyLines(yamlText string, firstLine int) map[string]int {
+	lines := make(map[string]int)
+	for i, line := range strings.Split(yamlText, "\n") {
+		if m := frontmatterKeyRe.FindStringSubmatch(line); m != nil {
+			key := strings.Trim(m[1], `"'`)
+			if _, seen := lines[key]; !seen {
+				lines[key] = firstLine + i
+			}
+		}
+	}
+	return lines
+}
+
+// lintFrontmatter checks one file's frontmatter against schema and returns
+// its findings.
+func lintFrontmatter(file, src string, schema map[string]frontmatterKind) []finding {
+	yamlText, firstLine, ok := splitFrontmatter(src)
+	if !ok {
+		return nil
+	}
+	var findings []finding
+	report := func(line int, format string, args ...interface{}) {
+		findings = append(findings, finding{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var fields yaml.MapSlice
+	if err := yaml.Unmarshal([]byte(yamlText), &fields); err != nil {
+		report(firstLine, "frontmatter is not valid YAML, so the build skips the file: %v", err)
+		return findings
+	}
+	lines := frontmatterKeyLines(yamlText, firstLine)
+
+	for _, field := range fields {
+		key := fmt.Sprint(field.Key)
+		line := lines[key]
+		kind, known := schema[key]
+		if !known {
+			if near := nearestKey(key, schema); near != "" {
+				report(line, "unknown key %q (did you mean %q?)", key, near)
+			} else {
+				report(line, "unknown key %q", key)
+			}
+			continue
+		}
+
+		switch kind {
+		case kindText:
+			switch v := field.Value.(type) {
+			case nil:
+				report(line, "%s has no value", key)
+			case []interface{}, yaml.MapSlice, map[interface{}]interface{}:
+				report(line, "%s must be text, not a %s, so the build skips the file", key, yamlShape(v))
+			case string:
+				checkTextLength(key, strings.TrimSpace(v), line, report)
+			}
+		case kindInt:
+			switch v := field.Value.(type) {
+			case int:
+			case float64:
+				report(line, "%s must be a whole number, not %v", key, v)
+			case nil:
+				report(line, "%s has no value", key)
+			default:
+				report(line, "%s must be a whole number, not %s, so the build skips the file", key, yamlShape(v))
+			}
+		case kindTerms:
+			var terms []string
+			switch v := field.Value.(type) {
+			case nil:
+			case []interface{}:
+				for _, item := range v {
+					if yamlCollection(item) {
+						report(line, "%s: an entry is a %s, so the whole list is dropped", key, yamlShape(item))
+						terms = nil
+						break
+					}
+					if item != nil {
+						terms = append(terms, fmt.Sprint(item))
+					}
+				}
+			case yaml.MapSlice, map[interface{}]interface{}:
+				report(line, "%s must be a list or space-separated words, not a %s, so it is dropped", key, yamlShape(v))
+			default:
+				terms = strings.Fields(fmt.Sprint(v))
+			}
+			for _, term := range terms {
+				for _, field := range strings.Fields(term) {
+					if slugifyTag(field) == "" {
+						report(line, "%s: %q has no letters or digits, so it is dropped", key, field)
+					}
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// checkTextLength flags a title or description outside its length range.
+func checkTextLength(key, value string, line int, report func(int, string, ...interface{})) {
+	var lo, hi int
+	switch key {
+	case "title":
+		lo, hi = minTitleLength, maxTitleLength
+	case "description":
+		lo, hi = minDescriptionLength, maxDescriptionLength
+	default:
+		return
+	}
+	switch n := utf8.RuneCountInString(value); {
+	case n == 0:
+		report(line, "%s is empty", key)
+	case n < lo:
+		report(line, "%s is %d characters; aim for at least %d", key, n, lo)
+	case n > hi:
+		report(line, "%s is %d characters; search results cut it after about %d", key, n, hi)
+	}
+}
+
+// yamlCollection reports whether a decoded YAML value is a list or mapping
+// rather than a scalar.
+func yamlCollection(v interface{}) bool {
+	switch v.(type) {
+	case []interface{}, yaml.MapSlice, map[interface{}]interface{}:
+		return true
+	}
+	return false
+}
+
+// yamlShape names a decoded YAML value's shape for a message.
+func yamlShape(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "empty value"
+	case []interface{}:
+		return "list"
+	case yaml.MapSlice, map[interface{}]interface{}:
+		return "mapping"
+	case string:
+		return fmt.Sprintf("%q", v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// nearestKey returns the known key closest to key by edit distance, if one is
+// close enough to be the likely intent.
+func nearestKey(key string, schema map[string]frontmatterKind) string {
+	best, bestDistance := "", 3
+	known := make([]string, 0, len(schema))
+	for k := range schema {
+		known = append(known, k)
+	}
+	sort.Strings(known)
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(key), k); d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur := make([]int, len(rb)+1)
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(rb)]
+}
+
+// postSlug is a post's name without its date prefix and extension: the part of
+// the filename a reader sees as the post's identity.
+func postSlug(filename string) string {
+	stem := strings.TrimSuffix(filename, filepath.Ext(filename))
+	if m := datePrefixRe.FindStringSubmatch(stem); m != nil {
+		return m[2]
+	}
+	return stem
+}
+
+// checkFrontmatter lints every post's frontmatter, then compares the posts
+// with each other and with the pages the build generates.
+func checkFrontmatter(ctx checkContext) ([]finding, error) {
+	entries, err := os.ReadDir(ctx.contentDir)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := make(map[string]frontmatterKind, len(frontmatterSchema)+len(ctx.cfg.Taxonomies))
+	for k, v := range frontmatterSchema {
+		schema[k] = v
+	}
+	generated := map[string]string{
+		"index.html":    "the home page",
+		"posts.html":    "the archive",
+		"404.html":      "the not-found page",
+		searchPagePath:  "the search page",
+		seriesIndexPath: "the series index",
+	}
+	for _, t := range ctx.cfg.Taxonomies {
+		schema[t.Name] = kindTerms
+		generated[t.Name+".html"] = "the " + t.Singular + " index"
+	}
+
+	var findings []finding
+	titles := make(map[string]string)
+	slugs := make(map[string]string)
+	outputs := make(map[string]string)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".md") && !strings.HasSuffix(name, ".markdown")) {
+			continue
+		}
+		path := filepath.Join(ctx.contentDir, name)
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		findings = append(findings, lintFrontmatter(path, string(src), schema)...)
+
+		post, err := parsePost(path)
+		if err != nil {
+			continue // the build skips the file; lintFrontmatter has said why
+		}
+
+		titleKey := strings.ToLower(strings.TrimSpace(post.Title))
+		if other, dup := titles[titleKey]; dup {
+			findings = append(findings, finding{File: path, Message: fmt.Sprintf("title %q is also %s's", post.Title, other)})
+		} else {
+			titles[titleKey] = path
+		}
+		// Two posts on one path lose one of them; two dated posts with one
+		// slug only read as the same post, so that is said only if the paths
+		// differ.
+		slug := postSlug(name)
+		if page, ok := generated[post.OutputFile]; ok {
+			findings = append(findings, finding{File: path, Message: fmt.Sprintf("builds to %s, which %s overwrites", post.OutputFile, page)})
+		} else if other, dup := outputs[post.OutputFile]; dup {
+			findings = append(findings, finding{File: path, Message: fmt.Sprintf("builds to %s, as %s does", post.OutputFile, other)})
+		} else if other, dup := slugs[slug]; dup {
+			findings = append(findings, finding{File: path, Message: fmt.Sprintf("slug %q is also %s's", slug, other)})
+		}
+		if _, dup := outputs[post.OutputFile]; !dup {
+			outputs[post.OutputFile] = path
+		}
+		if _, dup := slugs[slug]; !dup {
+			slugs[slug] = path
+		}
+	}
+	return findings, nil
+}
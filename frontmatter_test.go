@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// frontmatterReport runs lintFrontmatter over the default schema and returns
+// its findings one per line.
+func frontmatterReport(src string) string {
+	var lines []string
+	for _, f := range lintFrontmatter("post.md", src, frontmatterSchema) {
+		lines = append(lines, f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLintFrontmatter(t *testing.T) {
+	tests := map[string]struct {
+		src  string
+		want string
+	}{
+		"clean": {
+			"---\ntitle: Gated workflows\ndescription: How a pipeline of gated stages keeps a main branch releasable at all times.\ntags: ci cd\nlayout: post\n---\nBody.\n",
+			"",
+		},
+		"no frontmatter": {"Just a body.\n", ""},
+		"typo": {
+			"---\ntitle: Gated workflows\ndescripiton: How a pipeline of gated stages keeps a main branch releasable.\n---\n",
+			`post.md:3: unknown key "descripiton" (did you mean "description"?)`,
+		},
+		"unknown": {
+			"---\ntitle: Gated workflows\nweather: sunny\n---\n",
+			`post.md:3: unknown key "weather"`,
+		},
+		"title a list": {
+			"---\ntitle: [a, b]\n---\n",
+			"post.md:2: title must be text, not a list, so the build skips the file",
+		},
+		"series order": {
+			"---\ntitle: Part two\nseries: Robo\nseries_order: two\n---\n",
+			`post.md:4: series_order must be a whole number, not "two", so the build skips the file`,
+		},
+		"tags a mapping": {
+			"---\ntitle: Gated workflows\ntags:\n  ci: true\n---\n",
+			"post.md:3: tags must be a list or space-separated words, not a mapping, so it is dropped",
+		},
+		"tag list with a list": {
+			"---\ntitle: Gated workflows\ntags:\n  - ci\n  - [cd]\n---\n",
+			"post.md:3: tags: an entry is a list, so the whole list is dropped",
+		},
+		"tag of punctuation": {
+			"---\ntitle: Gated workflows\ntags: ci --- cd\n---\n",
+			`post.md:3: tags: "---" has no letters or digits, so it is dropped`,
+		},
+		"lengths": {
+			"---\ntitle: Go\ndescription: Too short.\n---\n",
+			"post.md:2: title is 2 characters; aim for at least 3\npost.md:3: description is 10 characters; aim for at least 50",
+		},
+		"too long": {
+			"---\ntitle: " + strings.Repeat("word ", 15) + "\n---\n",
+			"post.md:2: title is 74 characters; search results cut it after about 70",
+		},
+		"empty title": {
+			"---\ntitle:\n---\n",
+			"post.md:2: title has no value",
+		},
+		"broken yaml": {
+			"---\ntitle: Why: a story\n---\n",
+			"post.md:2: frontmatter is not valid YAML, so the build skips the file",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := frontmatterReport(tt.src)
+			if tt.want == "" || !strings.HasPrefix(tt.want, "post.md:2: frontmatter") {
+				if got != tt.want {
+					t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+				}
+				return
+			}
+			assertContains(t, got, tt.want)
+		})
+	}
+}
+
+func TestNearestKey(t *testing.T) {
+	tests := map[string]string{
+		"titel": "title", "Tags": "tags", "seriesorder": "series_order", "weather": "",
+	}
+	for key, want := range tests {
+		if got := nearestKey(key, frontmatterSchema); got != want {
+			t.Errorf("nearestKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// TestCheckFrontmatter checks the comparisons across posts, and that a
+// configured taxonomy's key is known.
+func TestCheckFrontmatter(t *testing.T) {
+	desc := "description: A description long enough to pass the length check on its own.\n"
+	dir := writeBuild(t, map[string]string{
+		"2023-01-01-hello.md": "---\ntitle: Hello\n" + desc + "stack: go\n---\nHi.\n",
+		"2024-01-01-hello.md": "---\ntitle: hello\n" + desc + "---\nHi again.\n",
+		"posts.md":            "---\ntitle: My posts\n" + desc + "---\nA page.\n",
+		"about.md":            "---\ntitle: About\n" + desc + "---\nMe.\n",
+		"about.markdown":      "---\ntitle: About me\n" + desc + "---\nAlso me.\n",
+		"notes.txt":           "not a post",
+	})
+	cfg := defaultSiteConfig()
+	cfg.Taxonomies = append(cfg.Taxonomies, taxonomyConfig{Name: "stack", Singular: "technology"})
+
+	findings, err := checkFrontmatter(checkContext{contentDir: dir, cfg: cfg})
+	if err != nil {
+		t.Fatalf("checkFrontmatter: %v", err)
+	}
+	sortFindings(findings)
+	var got []string
+	for _, f := range findings {
+		got = append(got, strings.TrimPrefix(f.String(), dir+string(filepath.Separator)))
+	}
+	want := []string{
+		"about.md: builds to about.html, as " + filepath.Join(dir, "about.markdown") + " does",
+		"posts.md: builds to posts.html, which the archive overwrites",
+		"2024-01-01-hello.md: title \"hello\" is also " + filepath.Join(dir, "2023-01-01-hello.md") + "'s",
+		"2024-01-01-hello.md: slug \"hello\" is also " + filepath.Join(dir, "2023-01-01-hello.md") + "'s",
+	}
+	for _, w := range want {
+		assertContains(t, strings.Join(got, "\n"), w)
+	}
+	if len(got) != len(want) {
+		t.Errorf("findings:\n%s\nwant %d", strings.Join(got, "\n"), len(want))
+	}
+}
+
+// TestRunCheckFrontmatterWithoutBuild checks a content check runs when there
+// is no build output to read.
+func TestRunCheckFrontmatterWithoutBuild(t *testing.T) {
+	dir := writeBuild(t, map[string]string{
+		"post.md": "---\ntitle: A post\nlayuot: post\n---\nBody.\n",
+	})
+	var out bytes.Buffer
+	status := runCheck([]string{"frontmatter", "-strict", "-content", dir, "-build", filepath.Join(dir, "absent")}, &out)
+	if status != 1 {
+		t.Errorf("status = %d with a finding under -strict, want 1", status)
+	}
+	assertContains(t, out.String(), `unknown key "layuot" (did you mean "layout"?)`, "frontmatter: 1 problem\n")
+}
@@ -0,0 +1,230 @@
+package main
+
+// Last-modified dates from git. A post's filename carries the day it was
+// published and nothing else, so an edit to an old post is invisible to
+// crawlers and feed readers. With dates.from_git set, the build asks git when
+// each source file was last committed: that date becomes the post's
+// article:modified_time, its dateModified and its sitemap lastmod, and a
+// listing page's lastmod is the newest of the posts it lists.
+//
+// A shallow clone — CI's default checkout — has only the newest commits, and
+// the oldest of them, the boundary, looks as if it added every file it holds.
+// A file whose last commit is a boundary commit has no date git can vouch for,
+// so it keeps the date it would have had without git.
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitDates maps source files, by absolute path, to the time of the last
+// commit that touched them.
+type gitDates struct {
+	dates   map[string]time.Time
+	shallow int // files skipped for ending at a shallow clone's boundary
+}
+
+// of returns the last commit time of the file at path, or the zero time when
+// git has none to give.
+func (g gitDates) of(path string) time.Time {
+	return g.dates[resolvePath(path)]
+}
+
+// resolvePath makes path absolute and resolves symlinks, so the same file
+// named two ways — a temp dir under /var and /private/var — is one key.
+func resolvePath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	return path
+}
+
+// git runs a git command in dir and returns its output.
+func git(dir string, args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return out, nil
+}
+
+// readGitDates reads the last commit date of every file under dirs from one
+// walk of the history. dirs that don't exist are skipped.
+func readGitDates(dirs ...string) (gitDates, error) {
+	g := gitDates{dates: make(map[string]time.Time)}
+	var paths []string
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err == nil {
+			paths = append(paths, resolvePath(dir))
+		}
+	}
+	if len(paths) == 0 {
+		return g, nil
+	}
+
+	top, err := git(paths[0], "rev-parse", "--show-toplevel")
+	if err != nil {
+		return g, err
+	}
+	root := resolvePath(strings.TrimSpace(string(top)))
+	boundary, err := shallowBoundary(root)
+	if err != nil {
+		return g, err
+	}
+
+	// Newest first, so a file's first appearance is its last commit. Each
+	// commit is a header line, marked with a NUL no path can contain, followed
+	// by the paths it touched relative to the top of the repository.
+	args := []string{"-c", "core.quotePath=false", "log", "--format=%x00%H %cI", "--name-only", "--no-renames", "--"}
+	out, err := git(root, append(args, paths...)...)
+	if err != nil {
+		return g, err
+	}
+	var hash string
+	var date time.Time
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header, ok := strings.CutPrefix(line, "\x00"); ok {
+			h, d, _ := strings.Cut(header, " ")
+			if date, err = time.Parse(time.RFC3339, d); err != nil {
+				return g, fmt.Errorf("git log: commit %s: %w", h, err)
+			}
+			hash = h
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		path := filepath.Join(root, filepath.FromSlash(line))
+		if _, seen := g.dates[path]; seen {
+			continue
+		}
+		if boundary[hash] {
+			g.dates[path] = time.Time{} // seen, but undated
+			g.shallow++
+			continue
+		}
+		g.dates[path] = date
+	}
+	return g, scanner.Err()
+}
+
+// shallowBoundary returns the boundary commits of a shallow clone, or none for
+// a full one.
+func shallowBoundary(root string) (map[string]bool, error) {
+	out, err := git(root, "rev-parse", "--is-shallow-repository")
+	if err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, err
+	}
+	out, err = git(root, "rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	boundary := make(map[string]bool)
+	for _, hash := range strings.Fields(string(data)) {
+		boundary[hash] = true
+	}
+	return boundary, nil
+}
+
+// applyGitDates sets each post's Modified from its source file's last commit,
+// when that was on a later day than the post's own date; an undated page takes
+// the commit date as it is. edited asks for the "last edited" line too.
+func applyGitDates(posts []*BlogPost, contentDir string, g gitDates, edited bool) {
+	for _, post := range posts {
+		modified := g.of(filepath.Join(contentDir, post.Filename))
+		if modified.IsZero() {
+			continue
+		}
+		if !post.Date.IsZero() && modified.UTC().Format("2006-01-02") <= post.Date.Format("2006-01-02") {
+			continue
+		}
+		post.Modified = modified
+		post.ShowEdited = edited && !post.Date.IsZero()
+	}
+}
+
+// lastModified is when the post last changed: its last edit if it has one,
+// or else the day it was published.
+func (p *BlogPost) lastModified() time.Time {
+	if !p.Modified.IsZero() {
+		return p.Modified
+	}
+	return p.Date
+}
+
+// renderEdited is the "last edited" line under a post edited after the day it
+// was published.
+func renderEdited(post *BlogPost) string {
+	if !post.ShowEdited || post.Modified.IsZero() {
+		return ""
+	}
+	day := post.Modified.UTC().Format("2006-01-02")
+	return fmt.Sprintf("<p class=\"post-edited\">Last edited <time datetime=\"%s\">%s</time></p>", day, day)
+}
+
+// listingDates gives each listing page in pages the newest last-modified date
+// of the posts it links to, read back from the page as built. A page that
+// links to no post gets none.
+func listingDates(buildDir string, pages []string, posts []*BlogPost) map[string]time.Time {
+	byPath := make(map[string]*BlogPost, len(posts))
+	for _, post := range posts {
+		byPath["/"+post.OutputFile] = post
+	}
+	dates := make(map[string]time.Time)
+	for _, page := range pages {
+		data, err := os.ReadFile(filepath.Join(buildDir, filepath.FromSlash(page)))
+		if err != nil {
+			continue
+		}
+		var newest time.Time
+		for _, tok := range scanHTML(string(data)) {
+			if tok.Kind != htmlStartTag || tok.Name != "a" {
+				continue
+			}
+			href, _ := tok.attr("href")
+			if post := byPath[href]; post != nil && post.lastModified().After(newest) {
+				newest = post.lastModified()
+			}
+		}
+		if !newest.IsZero() {
+			dates[page] = newest
+		}
+	}
+	return dates
+}
+
+// staticDates gives each static page its source file's last commit date.
+// pages are the build-relative paths copyStaticDir returned, which mirror
+// their place under staticDir.
+func staticDates(staticDir string, pages []string, g gitDates) map[string]time.Time {
+	dates := make(map[string]time.Time)
+	for _, page := range pages {
+		if modified := g.of(filepath.Join(staticDir, filepath.FromSlash(page))); !modified.IsZero() {
+			dates[page] = modified
+		}
+	}
+	return dates
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gitRepo makes a repository in a temp dir and returns its path, and a commit
+// function that writes files and commits them at a given time.
+func gitRepo(t *testing.T) (string, func(when string, files map[string]string)) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	run := func(env []string, args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	run(nil, "init", "-q")
+	commit := func(when string, files map[string]string) {
+		t.Helper()
+		for name, body := range files {
+			path := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		run(nil, "add", "-A")
+		run([]string{"GIT_AUTHOR_DATE=" + when, "GIT_COMMITTER_DATE=" + when},
+			"-c", "user.name=Test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "at "+when)
+	}
+	return dir, commit
+}
+
+func TestReadGitDates(t *testing.T) {
+	dir, commit := gitRepo(t)
+	commit("2023-01-15T09:00:00Z", map[string]string{
+		"content/2023-01-15-first.md": "first", "content/about.md": "me", "README.md": "readme",
+	})
+	commit("2024-05-01T12:30:00Z", map[string]string{"content/2023-01-15-first.md": "first, edited", "README.md": "edited"})
+
+	dates, err := readGitDates(filepath.Join(dir, "content"), filepath.Join(dir, "static"))
+	if err != nil {
+		t.Fatalf("readGitDates: %v", err)
+	}
+	tests := map[string]string{
+		"content/2023-01-15-first.md": "2024-05-01T12:30:00Z",
+		"content/about.md":            "2023-01-15T09:00:00Z",
+		"README.md":                   "", // outside the directories asked for
+		"content/draft.md":            "", // never committed
+	}
+	for name, want := range tests {
+		got := dates.of(filepath.Join(dir, filepath.FromSlash(name)))
+		if (want == "" && !got.IsZero()) || (want != "" && got.UTC().Format(time.RFC3339) != want) {
+			t.Errorf("%s: got %v, want %q", name, got, want)
+		}
+	}
+	if dates.shallow != 0 {
+		t.Errorf("shallow = %d in a full clone", dates.shallow)
+	}
+
+	if _, err := readGitDates(t.TempDir()); err == nil {
+		t.Error("expected an error outside a repository")
+	}
+}
+
+// TestReadGitDatesShallow checks a file whose history a shallow clone cut off
+// is left undated, and a file committed since the boundary is still dated.
+func TestReadGitDatesShallow(t *testing.T) {
+	dir, commit := gitRepo(t)
+	commit("2023-01-15T09:00:00Z", map[string]string{"content/old.md": "old"})
+	commit("2024-05-01T12:30:00Z", map[string]string{"content/new.md": "new"})
+	commit("2024-06-01T12:30:00Z", map[string]string{"content/newer.md": "newer"})
+
+	clone := filepath.Join(t.TempDir(), "clone")
+	if out, err := exec.Command("git", "clone", "-q", "--depth", "2", "file://"+dir, clone).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %v\n%s", err, out)
+	}
+	dates, err := readGitDates(filepath.Join(clone, "content"))
+	if err != nil {
+		t.Fatalf("readGitDates: %v", err)
+	}
+	if got := dates.of(filepath.Join(clone, "content", "newer.md")); got.UTC().Format("2006-01-02") != "2024-06-01" {
+		t.Errorf("newer.md = %v, want its commit date", got)
+	}
+	for _, name := range []string{"old.md", "new.md"} {
+		if got := dates.of(filepath.Join(clone, "content", name)); !got.IsZero() {
+			t.Errorf("%s = %v, want no date from the boundary commit", name, got)
+		}
+	}
+	if dates.shallow != 2 {
+		t.Errorf("shallow = %d, want 2", dates.shallow)
+	}
+}
+
+func TestApplyGitDates(t *testing.T) {
+	contentDir := t.TempDir()
+	dates := gitDates{dates: map[string]time.Time{
+		resolvePath(filepath.Join(contentDir, "edited.md")):    testDate(t, "2024-05-01"),
+		resolvePath(filepath.Join(contentDir, "same-day.md")):  testDate(t, "2023-01-15").Add(15 * time.Hour),
+		resolvePath(filepath.Join(contentDir, "about.md")):     testDate(t, "2024-02-02"),
+		resolvePath(filepath.Join(contentDir, "untracked.md")): {},
+	}}
+	edited := &BlogPost{Filename: "edited.md", Date: testDate(t, "2023-01-15")}
+	sameDay := &BlogPost{Filename: "same-day.md", Date: testDate(t, "2023-01-15")}
+	about := &BlogPost{Filename: "about.md"}
+	untracked := &BlogPost{Filename: "untracked.md", Date: testDate(t, "2023-01-15")}
+	applyGitDates([]*BlogPost{edited, sameDay, about, untracked}, contentDir, dates, true)
+
+	if edited.Modified.Format("2006-01-02") != "2024-05-01" || !edited.ShowEdited {
+		t.Errorf("edited = %v, %v", edited.Modified, edited.ShowEdited)
+	}
+	if !sameDay.Modified.IsZero() || !untracked.Modified.IsZero() {
+		t.Errorf("same day = %v, untracked = %v; want neither marked modified", sameDay.Modified, untracked.Modified)
+	}
+	if about.Modified.Format("2006-01-02") != "2024-02-02" || about.ShowEdited {
+		t.Errorf("about = %v, %v; want dated but no edited line", about.Modified, about.ShowEdited)
+	}
+	if got := untracked.lastModified(); !got.Equal(untracked.Date) {
+		t.Errorf("lastModified = %v, want the post date", got)
+	}
+
+	page := renderPost(edited, "<head>{{head_extra}}</head>{{content}}", nil)
+	assertContains(t, page,
+		`<meta property="article:modified_time" content="2024-05-01T00:00:00Z" />`,
+		`<p class="post-edited">Last edited <time datetime="2024-05-01">2024-05-01</time></p>`,
+		`"dateModified":"2024-05-01T00:00:00Z"`)
+}
+
+func TestListingDates(t *testing.T) {
+	posts := []*BlogPost{
+		{OutputFile: "a.html", Date: testDate(t, "2023-01-15"), Modified: testDate(t, "2024-05-01")},
+		{OutputFile: "b.html", Date: testDate(t, "2023-03-20")},
+	}
+	buildDir := writeBuild(t, map[string]string{
+		"posts.html":    `<a href="/a.html">A</a><a href="/b.html">B</a>`,
+		"tags/b.html":   `<a href="/b.html">B</a><a href="/posts.html">All</a>`,
+		"search.html":   `<a href="/posts.html">All</a>`,
+		"sitemap.xml":   "",
+		"elsewhere.txt": "",
+	})
+	dates := listingDates(buildDir, []string{"posts.html", "tags/b.html", "search.html", "missing.html"}, posts)
+	if got := dates["posts.html"].Format("2006-01-02"); got != "2024-05-01" {
+		t.Errorf("posts.html = %s, want the edited post's date", got)
+	}
+	if got := dates["tags/b.html"].Format("2006-01-02"); got != "2023-03-20" {
+		t.Errorf("tags/b.html = %s", got)
+	}
+	if _, ok := dates["search.html"]; ok {
+		t.Error("a page listing no post has a date")
+	}
+}
@@ -5,9 +5,7 @@ go 1.26.5
 require (
 	github.com/adrg/frontmatter v0.2.0
 	github.com/gomarkdown/markdown v0.0.0-20250207164621-7a1f277a159e
+	gopkg.in/yaml.v2 v2.3.0
 )
 
-require (
-	github.com/BurntSushi/toml v0.3.1 // indirect
-	gopkg.in/yaml.v2 v2.3.0 // indirect
-)
+require github.com/BurntSushi/toml v0.3.1 // indirect
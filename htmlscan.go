@@ -0,0 +1,225 @@
+package main
+
+// A small HTML tokenizer for reading the pages the build writes back in. It is
+// not a full HTML5 parser: it splits markup into tags, text and comments, with
+// each token's line, which is all the checks over the build output need, and
+// it never fails — malformed markup comes back as text for a check to report.
+
+import (
+	"html"
+	"strings"
+)
+
+// htmlTokenKind says what a token is.
+type htmlTokenKind int
+
+const (
+	htmlText htmlTokenKind = iota
+	htmlStartTag
+	htmlEndTag
+	htmlComment
+	htmlDoctype
+)
+
+// htmlAttr is one attribute, its value entity-decoded. A bare attribute such
+// as `defer` has an empty value.
+type htmlAttr struct {
+	Name  string
+	Value string
+}
+
+// htmlToken is one piece of a page. Name is the lowercased tag name for tags;
+// Text is the raw source for text, comments and doctypes. SelfClosing records
+// a trailing "/>", which HTML ignores but markup can still be judged by.
+type htmlToken struct {
+	Kind        htmlTokenKind
+	Name        string
+	Attrs       []htmlAttr
+	Text        string
+	SelfClosing bool
+	Line        int // 1-based line the token starts on
+	Start, End  int // byte offsets of the token in the source
+}
+
+// attr returns the value of the named attribute and whether it was present.
+func (t htmlToken) attr(name string) (string, bool) {
+	for _, a := range t.Attrs {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// htmlVoidElements never have an end tag.
+var htmlVoidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true, "hr": true,
+	"img": true, "input": true, "link": true, "meta": true, "source": true,
+	"track": true, "wbr": true,
+}
+
+// htmlRawTextElements hold text that isn't markup: everything up to the
+// matching end tag is one text token.
+var htmlRawTextElements = map[string]bool{"script": true, "style": true, "textarea": true, "title": true}
+
+// scanHTML splits src into tokens in document order.
+func scanHTML(src string) []htmlToken {
+	var tokens []htmlToken
+	line := 1
+	pos := 0
+	// emit appends a token covering src[start:end] and advances the line
+	// count past it.
+	emit := func(tok htmlToken, start, end int) {
+		tok.Line, tok.Start, tok.End = line, start, end
+		tokens = append(tokens, tok)
+		line += strings.Count(src[start:end], "\n")
+	}
+	text := func(start, end int) {
+		if end > start {
+			emit(htmlToken{Kind: htmlText, Text: src[start:end]}, start, end)
+		}
+	}
+
+	textStart := 0
+	for pos < len(src) {
+		lt := strings.IndexByte(src[pos:], '<')
+		if lt < 0 {
+			break
+		}
+		pos += lt
+		rest := src[pos:]
+
+		switch {
+		case strings.HasPrefix(rest, "<!--"):
+			text(textStart, pos)
+			end := strings.Index(rest[4:], "-->")
+			if end < 0 {
+				end = len(rest)
+			} else {
+				end += 4 + 3
+			}
+			emit(htmlToken{Kind: htmlComment, Text: rest[:end]}, pos, pos+end)
+			pos += end
+			textStart = pos
+			continue
+		case strings.HasPrefix(rest, "<!") || strings.HasPrefix(rest, "<?"):
+			text(textStart, pos)
+			end := strings.IndexByte(rest, '>')
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			emit(htmlToken{Kind: htmlDoctype, Text: rest[:end+1]}, pos, pos+end+1)
+			pos += end + 1
+			textStart = pos
+			continue
+		}
+
+		tok, n, ok := scanTag(rest)
+		if !ok {
+			// A "<" that opens no tag is just text, as a browser reads it.
+			pos++
+			continue
+		}
+		text(textStart, pos)
+		emit(tok, pos, pos+n)
+		pos += n
+		textStart = pos
+
+		if tok.Kind == htmlStartTag && htmlRawTextElements[tok.Name] && !tok.SelfClosing {
+			end := indexFold(src[pos:], "</"+tok.Name)
+			if end < 0 {
+				end = len(src) - pos
+			}
+			text(pos, pos+end)
+			pos += end
+			textStart = pos
+		}
+	}
+	text(textStart, len(src))
+	return tokens
+}
+
+// scanTag reads one start or end tag at the start of s, returning it and its
+// length in bytes. It reports false when s doesn't open a tag.
+func scanTag(s string) (htmlToken, int, bool) {
+	i := 1
+	tok := htmlToken{Kind: htmlStartTag}
+	if i < len(s) && s[i] == '/' {
+		tok.Kind = htmlEndTag
+		i++
+	}
+	nameStart := i
+	for i < len(s) && isTagNameByte(s[i]) {
+		i++
+	}
+	if i == nameStart || !isLetter(s[nameStart]) {
+		return tok, 0, false
+	}
+	tok.Name = strings.ToLower(s[nameStart:i])
+
+	for {
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i >= len(s) {
+			return tok, 0, false // ran off the end: not a tag after all
+		}
+		switch s[i] {
+		case '>':
+			return tok, i + 1, true
+		case '/':
+			if i+1 < len(s) && s[i+1] == '>' {
+				tok.SelfClosing = true
+				return tok, i + 2, true
+			}
+			i++
+			continue
+		}
+
+		nameStart := i
+		for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '=' && s[i] != '>' && !(s[i] == '/' && i+1 < len(s) && s[i+1] == '>') {
+			i++
+		}
+		attr := htmlAttr{Name: strings.ToLower(s[nameStart:i])}
+		for i < len(s) && isHTMLSpace(s[i]) {
+			i++
+		}
+		if i < len(s) && s[i] == '=' {
+			i++
+			for i < len(s) && isHTMLSpace(s[i]) {
+				i++
+			}
+			if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+				quote := s[i]
+				end := strings.IndexByte(s[i+1:], quote)
+				if end < 0 {
+					return tok, 0, false
+				}
+				attr.Value = html.UnescapeString(s[i+1 : i+1+end])
+				i += end + 2
+			} else {
+				valueStart := i
+				for i < len(s) && !isHTMLSpace(s[i]) && s[i] != '>' {
+					i++
+				}
+				attr.Value = html.UnescapeString(s[valueStart:i])
+			}
+		}
+		tok.Attrs = append(tok.Attrs, attr)
+	}
+}
+
+// isTagNameByte reports whether c can appear in a tag name.
+func isTagNameByte(c byte) bool {
+	return isLetter(c) || (c >= '0' && c <= '9') || c == '-'
+}
+
+// isHTMLSpace reports whether c is whitespace between attributes.
+func isHTMLSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// indexFold is strings.Index ignoring ASCII case in s; substr is lowercase.
+func indexFold(s, substr string) int {
+	return strings.Index(strings.ToLower(s), substr)
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScanHTML(t *testing.T) {
+	src := "<!DOCTYPE html>\n<p class=\"a\" data-x='1 &amp; 2' hidden>one\n<!-- a <b> comment -->\n<img src=x.png alt=\"\"/></p>\n<script>if (a < b) { x = \"</p>\" }</script>"
+	tokens := scanHTML(src)
+
+	var got []string
+	for _, tok := range tokens {
+		switch tok.Kind {
+		case htmlStartTag:
+			got = append(got, "<"+tok.Name+">")
+		case htmlEndTag:
+			got = append(got, "</"+tok.Name+">")
+		case htmlComment:
+			got = append(got, "comment")
+		case htmlDoctype:
+			got = append(got, "doctype")
+		case htmlText:
+			got = append(got, "text")
+		}
+	}
+	want := "doctype text <p> text comment text <img> </p> text <script> text </script>"
+	if strings.Join(got, " ") != want {
+		t.Fatalf("tokens = %s\nwant %s", strings.Join(got, " "), want)
+	}
+
+	p := tokens[2]
+	if v, _ := p.attr("data-x"); v != "1 & 2" {
+		t.Errorf("data-x = %q, want the entity decoded", v)
+	}
+	if _, ok := p.attr("hidden"); !ok {
+		t.Error("bare attribute hidden was dropped")
+	}
+	img := tokens[6]
+	if img.Line != 4 || !img.SelfClosing {
+		t.Errorf("img on line %d, self-closing %v; want line 4, true", img.Line, img.SelfClosing)
+	}
+	if alt, ok := img.attr("alt"); !ok || alt != "" {
+		t.Errorf("alt = %q, %v; want present and empty", alt, ok)
+	}
+	if script := tokens[10]; script.Text != `if (a < b) { x = "</p>" }` {
+		t.Errorf("script body = %q, want it kept whole as text", script.Text)
+	}
+	if src[p.Start:p.End] != `<p class="a" data-x='1 &amp; 2' hidden>` {
+		t.Errorf("offsets cover %q", src[p.Start:p.End])
+	}
+}
+
+// TestScanHTMLStrayAngle checks a "<" that opens no tag stays in the text, as
+// it does in a browser.
+func TestScanHTMLStrayAngle(t *testing.T) {
+	tokens := scanHTML("a < b and c <3 d <unterminated")
+	if len(tokens) != 1 || tokens[0].Kind != htmlText {
+		t.Fatalf("tokens = %+v, want one text token", tokens)
+	}
+}
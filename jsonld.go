@@ -0,0 +1,221 @@
+package main
+
+// Structured data: the schema.org JSON-LD search engines read from a page's
+// <head> alongside the Open Graph tags. A post is a BlogPosting; a listing —
+// the archive, a year or month, a term, an index of terms or series — is a
+// CollectionPage with a BreadcrumbList back to the home page; and the home page
+// describes the site and its author as a WebSite and Person graph. Every node
+// is built from the site identity constants in main.go, so the structured data
+// names the site and its author exactly as the feeds and the template do.
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const schemaContext = "https://schema.org"
+
+// The site and its author have fixed @ids, so every page's structured data
+// refers to the same two nodes.
+var (
+	siteID   = siteURL + "/#website"
+	authorID = siteURL + "/#author"
+)
+
+// ldNode is a schema.org thing named by reference: enough for a reader to know
+// what it is without the home page's graph to hand.
+type ldNode struct {
+	Type string `json:"@type"`
+	ID   string `json:"@id"`
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func siteNode() ldNode {
+	return ldNode{Type: "WebSite", ID: siteID, Name: siteName, URL: siteURL + "/"}
+}
+
+func authorNode() ldNode {
+	return ldNode{Type: "Person", ID: authorID, Name: siteAuthor, URL: canonicalURL("about.html")}
+}
+
+type ldWebSite struct {
+	ldNode
+	AlternateName   string         `json:"alternateName"`
+	Description     string         `json:"description"`
+	InLanguage      string         `json:"inLanguage"`
+	Author          ldNode         `json:"author"`
+	PotentialAction ldSearchAction `json:"potentialAction"`
+}
+
+// ldSearchAction tells a search engine how to search the site: search.html
+// reads its query from ?q=.
+type ldSearchAction struct {
+	Type       string `json:"@type"`
+	Target     string `json:"target"`
+	QueryInput string `json:"query-input"`
+}
+
+type ldPerson struct {
+	ldNode
+	Description string `json:"description"`
+}
+
+// ldGraph is a document of several top-level nodes.
+type ldGraph struct {
+	Context string        `json:"@context"`
+	Graph   []interface{} `json:"@graph"`
+}
+
+type ldBlogPosting struct {
+	Context          string   `json:"@context"`
+	Type             string   `json:"@type"`
+	ID               string   `json:"@id"`
+	URL              string   `json:"url"`
+	MainEntityOfPage string   `json:"mainEntityOfPage"`
+	Headline         string   `json:"headline"`
+	Description      string   `json:"description,omitempty"`
+	Image            string   `json:"image,omitempty"`
+	DatePublished    string   `json:"datePublished"`
+	DateModified     string   `json:"dateModified"`
+	Keywords         []string `json:"keywords,omitempty"`
+	InLanguage       string   `json:"inLanguage"`
+	Author           ldNode   `json:"author"`
+	Publisher        ldNode   `json:"publisher"`
+	IsPartOf         ldNode   `json:"isPartOf"`
+}
+
+type ldCollectionPage struct {
+	Context     string           `json:"@context"`
+	Type        string           `json:"@type"`
+	ID          string           `json:"@id"`
+	URL         string           `json:"url"`
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	InLanguage  string           `json:"inLanguage"`
+	IsPartOf    ldNode           `json:"isPartOf"`
+	Breadcrumb  ldBreadcrumbList `json:"breadcrumb"`
+	MainEntity  *ldItemList      `json:"mainEntity,omitempty"`
+}
+
+type ldBreadcrumbList struct {
+	Type            string       `json:"@type"`
+	ItemListElement []ldListItem `json:"itemListElement"`
+}
+
+type ldItemList struct {
+	Type            string       `json:"@type"`
+	NumberOfItems   int          `json:"numberOfItems"`
+	ItemListElement []ldListItem `json:"itemListElement"`
+}
+
+type ldListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Item     string `json:"item"`
+}
+
+// crumb is one step of a listing's breadcrumb trail: a name and the
+// build-relative path of the page it names.
+type crumb struct {
+	Name string
+	Path string
+}
+
+// renderJSONLD marshals v into a script element for the <head>. json.Marshal
+// escapes <, > and & as \u003c, \u003e and \u0026, so no title or description
+// can close the script early, and U+2028 and U+2029 too, which would end a
+// line inside a JavaScript string.
+func renderJSONLD(v interface{}) string {
+	// Every value passed in is one of this file's structs, built from strings,
+	// ints and slices of the same, and json.Marshal only fails on channels,
+	// functions, cycles and a failing Marshaler, so the error is always nil.
+	// Invalid UTF-8 in a title is replaced, not reported.
+	data, _ := json.Marshal(v)
+	return "<script type=\"application/ld+json\">" + string(data) + "</script>"
+}
+
+// homeJSONLD is the home page's graph: the site, and the person who writes it.
+func homeJSONLD() string {
+	return renderJSONLD(ldGraph{
+		Context: schemaContext,
+		Graph: []interface{}{
+			ldWebSite{
+				ldNode:        siteNode(),
+				AlternateName: siteTitle,
+				Description:   siteDescription,
+				InLanguage:    siteLanguage,
+				Author:        authorNode(),
+				PotentialAction: ldSearchAction{
+					Type:       "SearchAction",
+					Target:     canonicalURL(searchPagePath) + "?q={search_term_string}",
+					QueryInput: "required name=search_term_string",
+				},
+			},
+			ldPerson{ldNode: authorNode(), Description: siteDescription},
+		},
+	})
+}
+
+// postJSONLD describes a dated post. Its tags are its keywords, and its image
+// is its social card.
+func postJSONLD(post *BlogPost) string {
+	link := canonicalURL(post.OutputFile)
+	return renderJSONLD(ldBlogPosting{
+		Context:          schemaContext,
+		Type:             "BlogPosting",
+		ID:               link + "#article",
+		URL:              link,
+		MainEntityOfPage: link,
+		Headline:         post.Title,
+		Description:      post.Description,
+		Image:            cardURL(link),
+		DatePublished:    post.Date.Format(time.RFC3339),
+		DateModified:     post.lastModified().Format(time.RFC3339),
+		Keywords:         post.tags(),
+		InLanguage:       siteLanguage,
+		Author:           authorNode(),
+		Publisher:        authorNode(),
+		IsPartOf:         siteNode(),
+	})
+}
+
+// postItems lists posts for a collection's ItemList, numbered from offset+1 so
+// a listing's later pages carry on where the earlier ones stopped.
+func postItems(posts []*BlogPost, offset int) []ldListItem {
+	items := make([]ldListItem, 0, len(posts))
+	for i, post := range posts {
+		items = append(items, ldListItem{Type: "ListItem", Position: offset + i + 1, Name: post.Title, Item: canonicalURL(post.OutputFile)})
+	}
+	return items
+}
+
+// collectionJSONLD describes a listing page at path, reached from the home page
+// through trail; the listing itself is the trail's last step and needn't be in
+// it. items are what it lists, if they are worth naming.
+func collectionJSONLD(title, description, path string, trail []crumb, items []ldListItem) string {
+	link := canonicalURL(path)
+	steps := append([]crumb{{Name: siteName, Path: "index.html"}}, trail...)
+	steps = append(steps, crumb{Name: title, Path: path})
+	breadcrumbs := make([]ldListItem, 0, len(steps))
+	for i, step := range steps {
+		breadcrumbs = append(breadcrumbs, ldListItem{Type: "ListItem", Position: i + 1, Name: step.Name, Item: canonicalURL(step.Path)})
+	}
+
+	page := ldCollectionPage{
+		Context:     schemaContext,
+		Type:        "CollectionPage",
+		ID:          link,
+		URL:         link,
+		Name:        title,
+		Description: description,
+		InLanguage:  siteLanguage,
+		IsPartOf:    siteNode(),
+		Breadcrumb:  ldBreadcrumbList{Type: "BreadcrumbList", ItemListElement: breadcrumbs},
+	}
+	if len(items) > 0 {
+		page.MainEntity = &ldItemList{Type: "ItemList", NumberOfItems: len(items), ItemListElement: items}
+	}
+	return renderJSONLD(page)
+}
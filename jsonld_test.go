@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// decodeJSONLD unwraps the script element renderJSONLD writes and decodes it.
+func decodeJSONLD(t *testing.T, script string) map[string]interface{} {
+	t.Helper()
+	const open, end = `<script type="application/ld+json">`, "</script>"
+	if !strings.HasPrefix(script, open) || !strings.HasSuffix(script, end) {
+		t.Fatalf("not a JSON-LD script: %s", script)
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal([]byte(strings.TrimSuffix(strings.TrimPrefix(script, open), end)), &doc); err != nil {
+		t.Fatalf("invalid JSON-LD: %v\n%s", err, script)
+	}
+	return doc
+}
+
+func TestRenderJSONLDEscapes(t *testing.T) {
+	title := "Ending </script><script>alert(1)</script> & more "
+	script := renderJSONLD(map[string]string{"headline": title})
+	body := strings.TrimSuffix(script, "</script>")
+	if strings.ContainsAny(strings.TrimPrefix(body, `<script type="application/ld+json">`), "<>& ") {
+		t.Errorf("unescaped markup in %s", script)
+	}
+	if got := decodeJSONLD(t, script)["headline"]; got != title {
+		t.Errorf("headline = %q, want %q back", got, title)
+	}
+}
+
+func TestPostJSONLD(t *testing.T) {
+	post := &BlogPost{
+		Title: "Gated workflows", Description: "How gates keep main releasable.",
+		Date: testDate(t, "2023-01-15"), OutputFile: "2023-01-15-gated.html", Terms: map[string][]string{"tags": {"ci-cd", "devops"}},
+	}
+	doc := decodeJSONLD(t, postJSONLD(post))
+	want := map[string]string{
+		"@context":      "https://schema.org",
+		"@type":         "BlogPosting",
+		"headline":      "Gated workflows",
+		"url":           siteURL + "/2023-01-15-gated.html",
+		"image":         siteURL + "/cards/2023-01-15-gated.png",
+		"datePublished": "2023-01-15T00:00:00Z",
+	}
+	for key, value := range want {
+		if doc[key] != value {
+			t.Errorf("%s = %v, want %q", key, doc[key], value)
+		}
+	}
+	if author := doc["author"].(map[string]interface{}); author["name"] != siteAuthor || author["@id"] != authorID {
+		t.Errorf("author = %v", author)
+	}
+	if keywords, _ := json.Marshal(doc["keywords"]); string(keywords) != `["ci-cd","devops"]` {
+		t.Errorf("keywords = %s", keywords)
+	}
+}
+
+func TestCollectionJSONLD(t *testing.T) {
+	posts := []*BlogPost{{Title: "Third", OutputFile: "c.html"}}
+	trail := []crumb{{Name: "Tags", Path: "tags.html"}}
+	doc := decodeJSONLD(t, collectionJSONLD("AWS — page 2 of 2", "Posts tagged AWS.", "tags/aws/2.html", trail, postItems(posts, 2)))
+
+	if doc["@type"] != "CollectionPage" || doc["url"] != siteURL+"/tags/aws/2.html" {
+		t.Errorf("page = %v", doc)
+	}
+	var crumbs []string
+	for _, item := range doc["breadcrumb"].(map[string]interface{})["itemListElement"].([]interface{}) {
+		item := item.(map[string]interface{})
+		crumbs = append(crumbs, item["name"].(string)+" "+item["item"].(string))
+	}
+	want := []string{
+		siteName + " " + siteURL + "/",
+		"Tags " + siteURL + "/tags.html",
+		"AWS — page 2 of 2 " + siteURL + "/tags/aws/2.html",
+	}
+	if strings.Join(crumbs, "\n") != strings.Join(want, "\n") {
+		t.Errorf("breadcrumbs:\n%s\nwant:\n%s", strings.Join(crumbs, "\n"), strings.Join(want, "\n"))
+	}
+	items := doc["mainEntity"].(map[string]interface{})["itemListElement"].([]interface{})
+	if first := items[0].(map[string]interface{}); first["position"] != 3.0 || first["item"] != siteURL+"/c.html" {
+		t.Errorf("first item = %v, want position 3 on the second page", first)
+	}
+
+	if doc := decodeJSONLD(t, collectionJSONLD("Series", "", "series.html", nil, nil)); doc["mainEntity"] != nil {
+		t.Errorf("an empty listing has an ItemList: %v", doc["mainEntity"])
+	}
+}
+
+func TestHomeJSONLD(t *testing.T) {
+	doc := decodeJSONLD(t, homeJSONLD())
+	graph := doc["@graph"].([]interface{})
+	if len(graph) != 2 {
+		t.Fatalf("graph has %d nodes, want the site and its author", len(graph))
+	}
+	site, person := graph[0].(map[string]interface{}), graph[1].(map[string]interface{})
+	if site["@type"] != "WebSite" || site["@id"] != siteID || site["name"] != siteName {
+		t.Errorf("site = %v", site)
+	}
+	if person["@type"] != "Person" || person["@id"] != authorID || person["name"] != siteAuthor {
+		t.Errorf("person = %v", person)
+	}
+}
+
+// TestRenderPostJSONLD checks only dated posts are described as BlogPostings.
+func TestRenderPostJSONLD(t *testing.T) {
+	template := "<head>{{head_extra}}</head>"
+	post := &BlogPost{Title: "A post", Date: testDate(t, "2023-01-15"), OutputFile: "a.html"}
+	assertContains(t, renderPost(post, template, nil), `"@type":"BlogPosting"`)
+	if page := renderPost(&BlogPost{Title: "About", OutputFile: "about.html"}, template, nil); strings.Contains(page, "ld+json") {
+		t.Errorf("an undated page has structured data: %s", page)
+	}
+}
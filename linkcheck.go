@@ -0,0 +1,149 @@
+package main
+
+// The links check. Every href and src in the build output that points into the
+// site — root-relative, relative, or absolute on siteURL — must name a file the
+// build wrote or copied, and a #fragment must name an element on the page it
+// lands on. Links off the site are the external checker's business.
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// linkAttrs names the attributes that carry a link, by element.
+var linkAttrs = map[string]string{
+	"a": "href", "area": "href", "link": "href",
+	"img": "src", "script": "src", "iframe": "src", "source": "src",
+	"audio": "src", "video": "src", "track": "src", "embed": "src",
+	"form": "action",
+}
+
+// pageLink is one link out of a built page.
+type pageLink struct {
+	URL  string
+	Line int
+}
+
+// pageLinks returns every link in a page, in document order. A meta refresh
+// counts: it is how the alias redirect stubs send a reader on.
+func pageLinks(page *builtPage) []pageLink {
+	var links []pageLink
+	for _, tok := range page.Tokens {
+		if tok.Kind != htmlStartTag {
+			continue
+		}
+		if tok.Name == "meta" {
+			if equiv, _ := tok.attr("http-equiv"); strings.EqualFold(equiv, "refresh") {
+				content, _ := tok.attr("content")
+				if i := strings.Index(strings.ToLower(content), "url="); i >= 0 {
+					links = append(links, pageLink{URL: strings.TrimSpace(content[i+4:]), Line: tok.Line})
+				}
+			}
+			continue
+		}
+		attr, ok := linkAttrs[tok.Name]
+		if !ok {
+			continue
+		}
+		if ref, ok := tok.attr(attr); ok {
+			links = append(links, pageLink{URL: strings.TrimSpace(ref), Line: tok.Line})
+		}
+	}
+	return links
+}
+
+// internalTarget resolves ref, found on the page at from, to a build-relative
+// path and a fragment. It reports false for a link off the site, or one that
+// isn't to a document at all: mailto:, data: and the like.
+func internalTarget(from, ref string) (target, fragment string, internal bool, err error) {
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", "", false, err
+	}
+	if u.Scheme != "" || u.Host != "" {
+		site, _ := url.Parse(siteURL)
+		if !strings.EqualFold(u.Host, site.Host) || (u.Scheme != "" && u.Scheme != "http" && u.Scheme != "https") {
+			return "", "", false, nil
+		}
+		if u.Path == "" {
+			u.Path = "/"
+		}
+	}
+
+	switch {
+	case u.Path == "":
+		target = from // a bare #fragment, or ?query, stays on the page
+	case strings.HasPrefix(u.Path, "/"):
+		target = path.Clean(u.Path)
+	default:
+		target = path.Join("/", path.Dir(from), u.Path)
+	}
+	// A directory, or anything spelt as one, is served by its index.html.
+	if u.Path == "/" || strings.HasSuffix(u.Path, "/") {
+		target = path.Join(target, "index.html")
+	}
+	return strings.TrimPrefix(target, "/"), u.Fragment, true, nil
+}
+
+// resolveBuilt finds the file the server would answer target with: the file
+// itself, or failing that the directory's index.html or target.html, as
+// GitHub Pages serves them. It reports false when there is none.
+func resolveBuilt(buildDir, target string) (string, bool) {
+	if target == "" {
+		target = "index.html"
+	}
+	for _, candidate := range []string{target, target + "/index.html", target + ".html"} {
+		info, err := os.Stat(filepath.Join(buildDir, filepath.FromSlash(candidate)))
+		if err == nil && !info.IsDir() {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// checkLinks reports every internal link in the build output that leads
+// nowhere.
+func checkLinks(ctx checkContext) ([]finding, error) {
+	pages, err := readBuiltPages(ctx.buildDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var findings []finding
+	for _, from := range sortedPagePaths(pages) {
+		page := pages[from]
+		for _, link := range pageLinks(page) {
+			if link.URL == "" {
+				findings = append(findings, finding{File: page.File, Line: link.Line, Message: "empty link"})
+				continue
+			}
+			target, fragment, internal, err := internalTarget(from, link.URL)
+			if err != nil {
+				findings = append(findings, finding{File: page.File, Line: link.Line, Message: fmt.Sprintf("malformed link %q: %v", link.URL, err)})
+				continue
+			}
+			if !internal {
+				continue
+			}
+			resolved, ok := resolveBuilt(ctx.buildDir, target)
+			if !ok {
+				findings = append(findings, finding{File: page.File, Line: link.Line, Message: fmt.Sprintf("broken link %q: no %s in the build", link.URL, target)})
+				continue
+			}
+			if fragment == "" {
+				continue
+			}
+			dest, ok := pages[resolved]
+			// "#top" goes to the top of any page, element or not.
+			if !ok || dest.IDs[fragment] || fragment == "top" {
+				continue
+			}
+			findings = append(findings, finding{File: page.File, Line: link.Line, Message: fmt.Sprintf("broken link %q: no id %q on %s", link.URL, fragment, resolved)})
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBuild writes each build-relative path → body pair into a fresh build
+// directory.
+func writeBuild(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("creating %s: %v", name, err)
+		}
+		if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestInternalTarget(t *testing.T) {
+	tests := []struct {
+		from, ref        string
+		target, fragment string
+		internal         bool
+	}{
+		{"index.html", "/posts.html", "posts.html", "", true},
+		{"tags/aws.html", "../posts.html#year-2023", "posts.html", "year-2023", true},
+		{"tags/aws.html", "aws/2.html", "tags/aws/2.html", "", true},
+		{"tags/aws.html", "#top", "tags/aws.html", "top", true},
+		{"index.html", "/2023/", "2023/index.html", "", true},
+		{"index.html", "/", "index.html", "", true},
+		{"index.html", "https://letsbuild.cloud/tags.html", "tags.html", "", true},
+		{"index.html", "https://letsbuild.cloud", "index.html", "", true},
+		{"index.html", "https://github.com/x", "", "", false},
+		{"index.html", "//cdn.example.com/a.js", "", "", false},
+		{"index.html", "mailto:me@example.com", "", "", false},
+		{"index.html", "/a%20b.html", "a b.html", "", true},
+	}
+	for _, tt := range tests {
+		target, fragment, internal, err := internalTarget(tt.from, tt.ref)
+		if err != nil {
+			t.Errorf("internalTarget(%q, %q): %v", tt.from, tt.ref, err)
+			continue
+		}
+		if target != tt.target || fragment != tt.fragment || internal != tt.internal {
+			t.Errorf("internalTarget(%q, %q) = %q, %q, %v; want %q, %q, %v",
+				tt.from, tt.ref, target, fragment, internal, tt.target, tt.fragment, tt.internal)
+		}
+	}
+}
+
+func TestCheckLinks(t *testing.T) {
+	buildDir := writeBuild(t, map[string]string{
+		"index.html": "<html>\n<a href=\"/posts.html\">posts</a>\n<a href=\"/posts.html#year-2023\">2023</a>\n" +
+			"<a href=\"/2023/\">year</a> <a href=\"/about\">about</a>\n<a href=\"#top\">top</a>\n" +
+			"<link rel=\"canonical\" href=\"https://letsbuild.cloud/\" /><img src=\"/theme.css\" alt=\"\">\n" +
+			"<a href=\"https://example.com/nope.html\">off site</a>",
+		"posts.html":      "<h2 id=\"year-2023\">2023</h2>\n<a href=\"/2022-03-08-dont-lgtm.html\">old</a>\n<a href=\"#year-2021\">2021</a>",
+		"2023/index.html": "<a href=\"../posts.html\">up</a> <img src=\"chart.png\" alt=\"chart\">",
+		"about.html":      "<a name=\"me\"></a>",
+		"tags/ci.html":    "<meta http-equiv=\"refresh\" content=\"0; url=/tags/ci-cd.html\" />",
+		"theme.css":       "body {}",
+	})
+
+	findings, err := checkLinks(checkContext{buildDir: buildDir})
+	if err != nil {
+		t.Fatalf("checkLinks: %v", err)
+	}
+	sortFindings(findings)
+
+	var got []string
+	for _, f := range findings {
+		rel, _ := filepath.Rel(buildDir, f.File)
+		f.File = filepath.ToSlash(rel)
+		got = append(got, f.String())
+	}
+	want := []string{
+		`2023/index.html:1: broken link "chart.png": no 2023/chart.png in the build`,
+		`posts.html:2: broken link "/2022-03-08-dont-lgtm.html": no 2022-03-08-dont-lgtm.html in the build`,
+		`posts.html:3: broken link "#year-2021": no id "year-2021" on posts.html`,
+		`tags/ci.html:1: broken link "/tags/ci-cd.html": no tags/ci-cd.html in the build`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("findings:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/xml"
+	"flag"
 	"fmt"
 	"html"
 	"io"
@@ -13,27 +14,34 @@ import (
 	"sort"
 	"strings"
 	"time"
-	"unicode/utf8"
 
 	"github.com/adrg/frontmatter"
 )
 
-// Site identity. These feed canonical URLs, social cards, the RSS feed and the
-// sitemap, so they are the one place the public address of the site is written
-// down. siteURL carries no trailing slash.
+// Site identity. These feed canonical URLs, social cards, the RSS feed, the
+// sitemap and the structured data, so they are the one place the public
+// address of the site is written down. siteURL carries no trailing slash.
 const (
 	siteURL         = "https://letsbuild.cloud"
 	siteName        = "LetsBuild.cloud"
 	siteAuthor      = "Simon Bracegirdle"
 	siteTitle       = "Let's Build"
 	siteDescription = "Notes on building software, shipping it, and the engineering practices in between — by Simon Bracegirdle, a software engineer in Perth, Western Australia."
+	siteLanguage    = "en-AU"
 )
 
 // FrontMatter represents the metadata at the top of markdown files
 type FrontMatter struct {
 	Title       string  `yaml:"title"`
 	Description string  `yaml:"description"`
-	Tags        tagList `yaml:"tags"`
+	Series      string  `yaml:"series"`
+	SeriesOrder int     `yaml:"series_order"`
+	Related     tagList `yaml:"related"`
+	NotRelated  tagList `yaml:"not_related"`
+
+	// Params collects every other key, so a taxonomy configured in site.yaml
+	// can read its terms from the frontmatter key of the same name.
+	Params map[string]interface{} `yaml:",inline"`
 }
 
 // BlogPost represents metadata about a blog post
@@ -43,17 +51,33 @@ type BlogPost struct {
 	Filename    string
 	OutputFile  string
 	Description string
-	Tags        []string
+	Markdown    []byte                 // post body after the frontmatter, for re-rendering
+	HTML        string                 // rendered body, before the template wraps it
+	Excerpt     string                 // rendered HTML before <!--more-->; empty without the marker
+	SeriesName  string                 // series frontmatter, as written
+	SeriesOrder int                    // series_order frontmatter; 0 when unset
+	Series      *seriesGroup           // set by linkSeries once every post is parsed
+	Prev        *BlogPost              // the next older dated post; set by linkNeighbours
+	Next        *BlogPost              // the next newer dated post; set by linkNeighbours
+	RelatedPins []string               // related frontmatter: posts to list first
+	NotRelated  []string               // not_related frontmatter: posts never to list
+	Related     []*BlogPost            // set by linkRelated once every post is parsed
+	Params      map[string]interface{} // frontmatter keys FrontMatter doesn't name
+	Terms       map[string][]string    // terms per taxonomy, tags included; set by taxonomy.assign
+	Modified    time.Time              // last commit to the source, when later than Date; set by applyGitDates
+	ShowEdited  bool                   // show the "last edited" line; set by applyGitDates
 }
 
 // canonicalURL turns a build-relative output path into the absolute URL the
-// page is served from. index.html maps to the bare site root so the home page
-// has a single canonical form.
+// page is served from. An index.html maps to its bare directory — the home page
+// to the site root, a year archive to /2023/ — so each page has a single
+// canonical form.
 func canonicalURL(outputFile string) string {
-	if outputFile == "index.html" {
-		return siteURL + "/"
+	outputFile = strings.TrimPrefix(outputFile, "/")
+	if outputFile == "index.html" || strings.HasSuffix(outputFile, "/index.html") {
+		outputFile = strings.TrimSuffix(outputFile, "index.html")
 	}
-	return siteURL + "/" + strings.TrimPrefix(outputFile, "/")
+	return siteURL + "/" + outputFile
 }
 
 // pageMeta carries everything template.html needs to render one page. The
@@ -90,6 +114,7 @@ func renderPage(template string, m pageMeta) string {
 		{"{{file}}", m.File},
 		{"{{description}}", m.Description},
 		{"{{canonical}}", m.Canonical},
+		{"{{card}}", cardURL(m.Canonical)},
 		{"{{ogtype}}", m.OGType},
 	}
 
@@ -311,11 +336,33 @@ func renderReadingSection(groups []ShelfBooks) string {
 	return b.String()
 }
 
-// processMarkdownFile processes a single markdown file and returns the generated HTML
+// processMarkdownFile processes a single markdown file and returns the generated
+// HTML. It renders the post on its own, with no knowledge of the rest of the
+// site — not even site.yaml, so only the default taxonomies are shown;
+// generateSite parses every post first and renders them afterwards, so
+// cross-post navigation such as a series box can be filled in.
 func processMarkdownFile(filePath, template string) (string, string, *BlogPost, error) {
+	post, err := parsePost(filePath)
+	if err != nil {
+		return "", "", nil, err
+	}
+	taxonomies := defaultTaxonomies()
+	for _, tax := range taxonomies {
+		tax.assign(post)
+	}
+	return post.OutputFile, renderPost(post, template, taxonomies), post, nil
+}
+
+// datePrefixRe splits a dated post's filename stem, 2023-07-20-gated-workflows,
+// into its date and the rest.
+var datePrefixRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
+
+// parsePost reads one markdown file into a BlogPost: frontmatter, date, summary
+// and the rendered body, but not yet the page around it.
+func parsePost(filePath string) (*BlogPost, error) {
 	fileContent, err := os.ReadFile(filePath)
 	if err != nil {
-		return "", "", nil, fmt.Errorf("error reading file %s: %v", filePath, err)
+		return nil, fmt.Errorf("error reading file %s: %v", filePath, err)
 	}
 
 	// Parse frontmatter
@@ -329,7 +376,7 @@ func processMarkdownFile(filePath, template string) (string, string, *BlogPost,
 		// delimiters and the frontmatter keys as prose and made the first of
 		// them the meta description. Refusing the file is louder and cheaper to
 		// notice: the caller logs it and skips the post.
-		return "", "", nil, fmt.Errorf("error parsing frontmatter in %s: %w", filePath, err)
+		return nil, fmt.Errorf("error parsing frontmatter in %s: %w", filePath, err)
 	}
 
 	// Get filename and extract date
@@ -337,8 +384,7 @@ func processMarkdownFile(filePath, template string) (string, string, *BlogPost,
 	title := meta.Title
 
 	// Extract date from filename (yyyy-mm-dd-title.md)
-	dateRegex := regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})-(.+)$`)
-	matches := dateRegex.FindStringSubmatch(strings.TrimSuffix(filename, filepath.Ext(filename)))
+	matches := datePrefixRe.FindStringSubmatch(strings.TrimSuffix(filename, filepath.Ext(filename)))
 
 	var postDate time.Time
 	var filenameTitle string
@@ -375,6 +421,14 @@ func processMarkdownFile(filePath, template string) (string, string, *BlogPost,
 	// Parse markdown to HTML (code blocks are syntax-highlighted at build time)
 	htmlContent := renderMarkdown(content)
 
+	// The teaser listings show in place of the description, when the post
+	// marks one. It is rendered on its own so the cut is a clean one: tags
+	// opened before the marker are closed by the renderer, not left dangling.
+	excerpt := ""
+	if excerptMarkdown, ok := splitExcerpt(content); ok {
+		excerpt = strings.TrimSpace(string(renderMarkdown(excerptMarkdown)))
+	}
+
 	outputFilename := strings.TrimSuffix(filename, filepath.Ext(filename)) + ".html"
 
 	// Create blog post metadata
@@ -384,66 +438,107 @@ func processMarkdownFile(filePath, template string) (string, string, *BlogPost,
 		Filename:    filename,
 		OutputFile:  outputFilename,
 		Description: description,
-		Tags:        normaliseTags(meta.Tags),
+		Markdown:    content,
+		HTML:        string(htmlContent),
+		Excerpt:     excerpt,
+		SeriesName:  strings.TrimSpace(meta.Series),
+		SeriesOrder: meta.SeriesOrder,
+		RelatedPins: meta.Related,
+		NotRelated:  meta.NotRelated,
+		Params:      meta.Params,
 	}
 
+	return blogPost, nil
+}
+
+// renderPost wraps a parsed post's body in the template. Anything that depends
+// on other posts — the series box — is read from fields linkSeries has already
+// filled in, and is simply absent when it hasn't run. Each taxonomy's chips are
+// shown, in order.
+func renderPost(post *BlogPost, template string, taxonomies []*taxonomy) string {
 	// Dated posts are articles; undated pages (about, and anything else) are
 	// ordinary pages. Only articles carry a published time.
 	ogType := "website"
 	headExtra := ""
-	if !postDate.IsZero() {
+	if !post.Date.IsZero() {
 		ogType = "article"
 		headExtra = fmt.Sprintf("<meta property=\"article:published_time\" content=\"%s\" />",
-			html.EscapeString(postDate.Format(time.RFC3339)))
+			html.EscapeString(post.Date.Format(time.RFC3339)))
+		if !post.Modified.IsZero() {
+			headExtra += fmt.Sprintf("<meta property=\"article:modified_time\" content=\"%s\" />",
+				html.EscapeString(post.Modified.Format(time.RFC3339)))
+		}
+		for _, tag := range post.tags() {
+			headExtra += fmt.Sprintf("<meta property=\"article:tag\" content=\"%s\" />", html.EscapeString(tag))
+		}
+		headExtra += postJSONLD(post)
 	}
+	headExtra += renderNeighbourLinks(post)
 
-	output := renderPage(template, pageMeta{
-		Title:       title,
-		File:        outputFilename,
-		Description: description,
-		Canonical:   canonicalURL(outputFilename),
+	return renderPage(template, pageMeta{
+		Title:       post.Title,
+		File:        post.OutputFile,
+		Description: post.Description,
+		Canonical:   canonicalURL(post.OutputFile),
 		OGType:      ogType,
 		HeadExtra:   headExtra,
-		// Tag chips sit at the top of the body, above the prose, the way a
-		// file header states what a document is about.
-		Content: renderTagChips(blogPost.Tags) + string(htmlContent),
+		// Term chips sit at the top of the body, above the prose, the way a
+		// file header states what a document is about. The series box follows
+		// for the same reason: which series, and where in it, is context for
+		// what comes next.
+		Content: renderPostTerms(taxonomies, post) + renderSeriesBox(post) + post.HTML + renderEdited(post) + renderRelated(post) + renderPostNav(post),
 	})
-
-	return outputFilename, output, blogPost, nil
 }
 
-// extractDescription extracts a brief description from the content
-func extractDescription(content []byte) string {
-	// Simple approach: get first paragraph or first 150 chars
-	text := string(content)
-	// Remove any markdown formatting
-	text = strings.ReplaceAll(text, "#", "")
-	text = strings.ReplaceAll(text, "*", "")
-	text = strings.ReplaceAll(text, "_", "")
-
-	// Find first paragraph
-	paragraphs := strings.Split(text, "\n\n")
-	for _, p := range paragraphs {
-		p = strings.TrimSpace(p)
-		if p != "" {
-			return truncateRunes(p, 150)
+// linkNeighbours points every dated post at the posts either side of it in
+// chronological order. It walks datedPostsNewestFirst rather than sorting
+// again, so two posts sharing a date sit in the same order here as on the index
+// and in the feed. Undated pages are left without neighbours.
+func linkNeighbours(posts []*BlogPost) {
+	dated := datedPostsNewestFirst(posts)
+	for i, post := range dated {
+		post.Prev, post.Next = nil, nil
+		if i > 0 {
+			post.Next = dated[i-1]
+		}
+		if i+1 < len(dated) {
+			post.Prev = dated[i+1]
 		}
 	}
+}
 
-	// Fallback to first 150 chars if no paragraph found
-	return truncateRunes(strings.TrimSpace(text), 150)
+// renderNeighbourLinks is the rel=prev/rel=next pair for a post's <head>, with
+// absolute URLs like the canonical link beside it.
+func renderNeighbourLinks(post *BlogPost) string {
+	var b strings.Builder
+	if post.Prev != nil {
+		b.WriteString(fmt.Sprintf("<link rel=\"prev\" href=\"%s\" />", html.EscapeString(canonicalURL(post.Prev.OutputFile))))
+	}
+	if post.Next != nil {
+		b.WriteString(fmt.Sprintf("<link rel=\"next\" href=\"%s\" />", html.EscapeString(canonicalURL(post.Next.OutputFile))))
+	}
+	return b.String()
 }
 
-// truncateRunes shortens s to at most limit characters, appending an ellipsis
-// when it cuts. It counts runes rather than bytes: this text lands in
-// <meta description> and og:description, and slicing a multi-byte rune down the
-// middle — an em dash or a curly quote straddling the cut — emits invalid UTF-8
-// that escaping cannot repair.
-func truncateRunes(s string, limit int) string {
-	if utf8.RuneCountInString(s) <= limit {
-		return s
+// renderPostNav renders the older/newer links at the foot of a post, so a
+// reader who reaches the end has somewhere to go other than back. The first and
+// last posts get only the side that exists; undated pages get nothing.
+func renderPostNav(post *BlogPost) string {
+	if post.Prev == nil && post.Next == nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<nav class=\"post-nav\" aria-label=\"More posts\">")
+	if post.Prev != nil {
+		b.WriteString(fmt.Sprintf("<a class=\"prev\" href=\"/%s\" rel=\"prev\"><span>&larr; Older</span>%s</a>",
+			html.EscapeString(post.Prev.OutputFile), html.EscapeString(post.Prev.Title)))
+	}
+	if post.Next != nil {
+		b.WriteString(fmt.Sprintf("<a class=\"next\" href=\"/%s\" rel=\"next\"><span>Newer &rarr;</span>%s</a>",
+			html.EscapeString(post.Next.OutputFile), html.EscapeString(post.Next.Title)))
 	}
-	return string([]rune(s)[:limit-3]) + "..."
+	b.WriteString("</nav>")
+	return b.String()
 }
 
 // latestPostCount is how many posts the landing page lists before linking out
@@ -474,16 +569,22 @@ func datedPostsNewestFirst(posts []*BlogPost) []*BlogPost {
 }
 
 // renderPostList renders posts as ul.post-list, newest first: gold ISO date,
-// linked title, one-line description. Callers pass an already-filtered,
-// already-sorted slice. Links are root-absolute so the same markup works from
-// the site root and from pages nested under /tags/.
-func renderPostList(posts []*BlogPost) string {
+// linked title, a series label for a post in one, and a one-line description.
+// With withExcerpts set, a post that marks an excerpt shows it in place of the
+// description; the archive leaves it unset to stay one line per post. Callers
+// pass an already-filtered, already-sorted slice. Links are root-absolute so
+// the same markup works from the site root and from pages nested under /tags/.
+func renderPostList(posts []*BlogPost, withExcerpts bool) string {
 	var b strings.Builder
 	b.WriteString("<ul class=\"post-list\">")
 	for _, post := range posts {
 		formattedDate := post.Date.Format("2006-01-02")
-		b.WriteString(fmt.Sprintf("<li><span class=\"date\">%s</span><a href=\"/%s\">%s</a><p>%s</p></li>\n",
-			formattedDate, post.OutputFile, html.EscapeString(post.Title), html.EscapeString(post.Description)))
+		summary := fmt.Sprintf("<p>%s</p>", html.EscapeString(post.Description))
+		if withExcerpts && post.Excerpt != "" {
+			summary = fmt.Sprintf("<div class=\"excerpt\">%s</div>", post.Excerpt)
+		}
+		b.WriteString(fmt.Sprintf("<li><span class=\"date\">%s</span><a href=\"/%s\">%s</a>%s%s</li>\n",
+			formattedDate, post.OutputFile, html.EscapeString(post.Title), renderSeriesLabel(post), summary))
 	}
 	b.WriteString("</ul>")
 	return b.String()
@@ -522,7 +623,7 @@ func generateIndex(posts []*BlogPost, template string, buildDir string, shelves
 	if len(latest) > latestPostCount {
 		latest = latest[:latestPostCount]
 	}
-	contentBuilder.WriteString(renderPostList(latest))
+	contentBuilder.WriteString(renderPostList(latest, true))
 
 	if len(dated) > len(latest) {
 		contentBuilder.WriteString("<p><a href=\"/posts.html\">All posts &rarr;</a> &middot; <a href=\"/tags.html\">browse by tag &rarr;</a></p>")
@@ -533,6 +634,7 @@ func generateIndex(posts []*BlogPost, template string, buildDir string, shelves
 		File:        "index.html",
 		Description: siteDescription,
 		Canonical:   canonicalURL("index.html"),
+		HeadExtra:   homeJSONLD(),
 		Content:     contentBuilder.String(),
 	})
 
@@ -547,29 +649,43 @@ func generateIndex(posts []*BlogPost, template string, buildDir string, shelves
 	return nil
 }
 
-// generateArchive generates posts.html: every dated post, newest first.
-func generateArchive(posts []*BlogPost, template string, buildDir string) error {
+// generateArchive generates posts.html: every dated post, newest first, under a
+// heading per year. A positive pageSize splits the list across posts/2.html
+// onwards. It returns the build-relative path of every page written, for the
+// sitemap.
+func generateArchive(posts []*BlogPost, template string, buildDir string, pageSize int) ([]string, error) {
 	dated := datedPostsNewestFirst(posts)
+	pages := newPaginated("posts.html", len(dated), pageSize)
+	description := fmt.Sprintf("Every post on %s — %d of them, newest first.", siteName, len(dated))
+
+	for n := 1; n <= pages.total; n++ {
+		var contentBuilder strings.Builder
+		contentBuilder.WriteString(renderPostsByYear(pages.slice(dated, n)))
+		contentBuilder.WriteString(pages.pager(n))
+		contentBuilder.WriteString("<p><a href=\"/\">&larr; Home</a> &middot; <a href=\"/tags.html\">browse by tag &rarr;</a></p>")
+
+		path := pages.path(n)
+		title := pages.title("All posts", n)
+		output := renderPage(template, pageMeta{
+			Title:       title,
+			File:        path,
+			Description: description,
+			Canonical:   canonicalURL(path),
+			HeadExtra:   pages.headLinks(n) + collectionJSONLD(title, description, path, nil, postItems(pages.slice(dated, n), (n-1)*pages.size)),
+			Content:     contentBuilder.String(),
+		})
 
-	var contentBuilder strings.Builder
-	contentBuilder.WriteString(renderPostList(dated))
-	contentBuilder.WriteString("<p><a href=\"/\">&larr; Home</a> &middot; <a href=\"/tags.html\">browse by tag &rarr;</a></p>")
-
-	output := renderPage(template, pageMeta{
-		Title:       "All posts",
-		File:        "posts.html",
-		Description: fmt.Sprintf("Every post on %s — %d of them, newest first.", siteName, len(dated)),
-		Canonical:   canonicalURL("posts.html"),
-		Content:     contentBuilder.String(),
-	})
-
-	outputPath := filepath.Join(buildDir, "posts.html")
-	if err := os.WriteFile(outputPath, []byte(output), 0644); err != nil {
-		return fmt.Errorf("error writing archive file: %v", err)
+		outputPath := filepath.Join(buildDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return nil, fmt.Errorf("error creating archive directory: %v", err)
+		}
+		if err := os.WriteFile(outputPath, []byte(output), 0644); err != nil {
+			return nil, fmt.Errorf("error writing archive file: %v", err)
+		}
 	}
 
-	fmt.Printf("Generated archive: %s\n", outputPath)
-	return nil
+	fmt.Printf("Generated archive: %s (%d pages)\n", filepath.Join(buildDir, "posts.html"), pages.total)
+	return pages.paths(), nil
 }
 
 // generateNotFound writes 404.html, which GitHub Pages serves for any unknown
@@ -658,6 +774,11 @@ func generateSite(contentDir, buildDir, templatePath string, shelves []ShelfBook
 		}
 	}
 
+	cfg, err := loadSiteConfig(siteConfigPath)
+	if err != nil {
+		return err
+	}
+
 	// Copy standalone resources from static/ before generating posts, so any
 	// generated page takes precedence on a name collision.
 	staticDir := filepath.Join(".", "static")
@@ -677,6 +798,17 @@ func generateSite(contentDir, buildDir, templatePath string, shelves []ShelfBook
 	}
 	template := string(templateBytes)
 
+	// Assets are fingerprinted before any page is rendered, so the template's
+	// {{asset:...}} references can name the hashed files.
+	assets := assetMap{}
+	if cfg.Assets.Fingerprint {
+		if assets, err = fingerprintAssets(staticDir, buildDir, cfg.Minify.CSS); err != nil {
+			log.Printf("Error fingerprinting assets: %v", err)
+		}
+	}
+	template = resolveAssetPlaceholders(template, assets)
+	template = strings.ReplaceAll(template, "{{feeds}}", renderFeedFormatAlternates())
+
 	// Check content directory
 	if _, err := os.Stat(contentDir); os.IsNotExist(err) {
 		return fmt.Errorf("content directory not found at %s", contentDir)
@@ -688,10 +820,11 @@ func generateSite(contentDir, buildDir, templatePath string, shelves []ShelfBook
 		return fmt.Errorf("error reading content directory: %v", err)
 	}
 
-	// Collection of blog posts for the index
+	// Parse every post before writing any of them: a post's page can depend
+	// on the others — the series box lists every part, the older/newer links
+	// name the posts either side, and the related posts are ranked against
+	// all the rest — so the whole set has to be known first.
 	var blogPosts []*BlogPost
-
-	// Process each markdown file
 	for _, file := range files {
 		// Skip directories and non-markdown files
 		if file.IsDir() ||
@@ -699,22 +832,48 @@ func generateSite(contentDir, buildDir, templatePath string, shelves []ShelfBook
 			continue
 		}
 
-		filePath := filepath.Join(contentDir, file.Name())
-		outputFilename, outputContent, blogPost, err := processMarkdownFile(filePath, template)
+		blogPost, err := parsePost(filepath.Join(contentDir, file.Name()))
 		if err != nil {
 			log.Printf("%v", err)
 			continue
 		}
+		blogPosts = append(blogPosts, blogPost)
+	}
 
-		// Add to collection of blog posts
-		if blogPost != nil {
-			blogPosts = append(blogPosts, blogPost)
+	// Assign each post its terms, aliases folded, before anything reads
+	// them: the chips, the related-post ranking, the feeds and the term pages
+	// all see only canonical terms.
+	taxonomies, err := loadTaxonomies(cfg.Taxonomies, contentDir)
+	if err != nil {
+		return err
+	}
+	for _, post := range blogPosts {
+		for _, tax := range taxonomies {
+			tax.assign(post)
 		}
+	}
 
-		// Write output file
-		outputPath := filepath.Join(buildDir, outputFilename)
-		err = os.WriteFile(outputPath, []byte(outputContent), 0644)
-		if err != nil {
+	// Last-modified dates come from git when site.yaml asks for them. Without
+	// git, or outside a repository, the build goes on with the post dates.
+	var pageDates map[string]time.Time
+	var dates gitDates
+	if cfg.Dates.FromGit {
+		if dates, err = readGitDates(contentDir, staticDir); err != nil {
+			log.Printf("warning: dates.from_git: %v; using post dates", err)
+		} else if dates.shallow > 0 {
+			log.Printf("warning: dates.from_git: a shallow clone has no history for %d files; they keep their post dates (fetch the full history to date them)", dates.shallow)
+		}
+		applyGitDates(blogPosts, contentDir, dates, cfg.Dates.ShowEdited)
+	}
+
+	series := linkSeries(blogPosts)
+	linkNeighbours(blogPosts)
+	linkRelated(blogPosts)
+
+	// Write each post's page
+	for _, post := range blogPosts {
+		outputPath := filepath.Join(buildDir, post.OutputFile)
+		if err := os.WriteFile(outputPath, []byte(renderPost(post, template, taxonomies)), 0644); err != nil {
 			log.Printf("Error writing output file %s: %v", outputPath, err)
 			continue
 		}
@@ -722,38 +881,68 @@ func generateSite(contentDir, buildDir, templatePath string, shelves []ShelfBook
 		fmt.Printf("Generated: %s\n", outputPath)
 	}
 
-	// Generate index, archive and tag pages
-	var tagPages []string
+	// Generate index, archive, taxonomy and series pages
+	var listingPages []string
 	if len(blogPosts) > 0 {
 		if err := generateIndex(blogPosts, template, buildDir, shelves); err != nil {
 			log.Printf("Error generating index: %v", err)
 		}
-		if err := generateArchive(blogPosts, template, buildDir); err != nil {
+		archivePages, err := generateArchive(blogPosts, template, buildDir, cfg.Pagination.Archive)
+		if err != nil {
 			log.Printf("Error generating archive: %v", err)
 		}
-		var err error
-		if tagPages, err = generateTagPages(blogPosts, template, buildDir); err != nil {
-			log.Printf("Error generating tag pages: %v", err)
+		listingPages = append(listingPages, archivePages...)
+		datePages, err := generateDateArchives(blogPosts, template, buildDir, cfg.Archives.Months)
+		if err != nil {
+			log.Printf("Error generating date archives: %v", err)
+		}
+		listingPages = append(listingPages, datePages...)
+		for _, tax := range taxonomies {
+			termPages, err := generateTaxonomyPages(tax, blogPosts, template, buildDir, cfg)
+			if err != nil {
+				log.Printf("Error generating %s pages: %v", tax.Singular, err)
+			}
+			listingPages = append(listingPages, termPages...)
+		}
+		if len(series) > 0 {
+			if err := generateSeriesIndex(series, template, buildDir); err != nil {
+				log.Printf("Error generating series index: %v", err)
+			} else {
+				listingPages = append(listingPages, seriesIndexPath)
+			}
+		}
+		if err := generateSearch(blogPosts, template, buildDir); err != nil {
+			log.Printf("Error generating search: %v", err)
+		} else {
+			listingPages = append(listingPages, searchPagePath)
 		}
 	}
 
 	// Machine-readable outputs: feed for readers, sitemap and robots.txt for
 	// crawlers. Each is best-effort — a failure here shouldn't lose the pages
 	// that already generated.
-	if err := generateFeed(blogPosts, buildDir); err != nil {
+	if err := generateFeed(blogPosts, buildDir, cfg.Feed); err != nil {
 		log.Printf("Error generating feed: %v", err)
 	}
 	// Pages the sitemap lists beyond the posts themselves. The generated
-	// listings only exist when there was at least one post to list, and the tag
-	// pages only when at least one post carried a tag — generateTagPages
-	// reports exactly what it wrote.
+	// listings only exist when there was at least one post to list, a
+	// taxonomy's pages only when at least one post carried a term —
+	// generateArchive, generateDateArchives and generateTaxonomyPages report
+	// exactly what they wrote — the series index only when a post declared a
+	// series, and the search page only when it was written.
 	var pages []string
 	if len(blogPosts) > 0 {
-		pages = append(pages, "index.html", "posts.html")
+		pages = append(pages, "index.html")
 	}
-	pages = append(pages, tagPages...)
+	pages = append(pages, listingPages...)
 	pages = append(pages, staticPages...)
-	if err := generateSitemap(blogPosts, pages, buildDir); err != nil {
+	if cfg.Dates.FromGit {
+		pageDates = listingDates(buildDir, pages, blogPosts)
+		for page, date := range staticDates(staticDir, staticPages, dates) {
+			pageDates[page] = date
+		}
+	}
+	if err := generateSitemap(blogPosts, pages, pageDates, buildDir); err != nil {
 		log.Printf("Error generating sitemap: %v", err)
 	}
 	if err := generateRobots(buildDir); err != nil {
@@ -762,11 +951,33 @@ func generateSite(contentDir, buildDir, templatePath string, shelves []ShelfBook
 	if err := generateNotFound(template, buildDir); err != nil {
 		log.Printf("Error generating 404 page: %v", err)
 	}
+	// Last, once every page is written: each card is drawn from its page.
+	if err := generateCards(buildDir); err != nil {
+		log.Printf("Error generating social cards: %v", err)
+	}
+	// Minifying comes after everything that reads the pages back.
+	if err := minifyBuild(buildDir, cfg.Minify, assets); err != nil {
+		log.Printf("Error minifying the build: %v", err)
+	}
+	// Then every page, static ones included, is pointed at the hashed assets.
+	if cfg.Assets.Fingerprint {
+		if err := rewriteBuildAssets(buildDir, assets); err != nil {
+			log.Printf("Error rewriting asset references: %v", err)
+		}
+	}
 
 	return nil
 }
 
 func main() {
+	// A subcommand reads or checks the site instead of building it.
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+	if args := flag.Args(); len(args) > 0 {
+		os.Exit(runCommand(args, os.Stdout))
+	}
+
 	contentDir := filepath.Join(".", "content")
 	buildDir := filepath.Join(".", "build")
 	templatePath := filepath.Join(".", "template.html")
@@ -263,28 +263,21 @@ func TestExtractDescription(t *testing.T) {
 			description: "Should return the full paragraph when under 150 chars",
 		},
 		{
-			name:    "Long paragraph",
-			content: []byte(strings.Repeat(repeatStr, 10)),
-			expected: func() string {
-				// Calculate how many repetitions fit in 147 chars
-				fullText := strings.Repeat(repeatStr, 10)
-				if len(fullText) > 147 {
-					return fullText[:147] + "..."
-				}
-				return fullText // In case the string is shorter than expected
-			}(),
-			description: "Should truncate long paragraphs to 150 chars",
+			name:        "Long paragraph",
+			content:     []byte(strings.Repeat(repeatStr, 10)),
+			expected:    strings.TrimSpace(strings.Repeat(repeatStr, 3)),
+			description: "Should cut a long paragraph at the last sentence that fits in 150 chars",
 		},
 		{
 			name:        "Multiple paragraphs",
 			content:     []byte("# Heading\n\nFirst paragraph.\n\nSecond paragraph."),
-			expected:    "Heading",
-			description: "Should extract the first non-empty paragraph after headers",
+			expected:    "First paragraph.",
+			description: "Should skip the heading and take the first paragraph",
 		},
 		{
 			name:        "With markdown formatting",
 			content:     []byte("# Heading\n\n**Bold text** and *italic* formatting."),
-			expected:    "Heading",
+			expected:    "Bold text and italic formatting.",
 			description: "Should remove markdown formatting",
 		},
 	}
@@ -677,6 +670,8 @@ func TestFullSiteGeneration(t *testing.T) {
 		"index.html",
 		"posts.html",
 		"feed.xml",
+		"feed.atom",
+		"feed.json",
 		"sitemap.xml",
 		"robots.txt",
 		"404.html",
@@ -696,9 +691,10 @@ func TestFullSiteGeneration(t *testing.T) {
 		t.Fatalf("Error reading index file: %v", err)
 	}
 
-	// Check post order by date (newest first)
+	// Check post order by date (newest first). The third post has no
+	// frontmatter, so its title comes from the filename.
 	secondIndex := strings.Index(string(content), "Second Post")
-	thirdIndex := strings.Index(string(content), "Third Post")
+	thirdIndex := strings.Index(string(content), "third post")
 	firstIndex := strings.Index(string(content), "First Post")
 
 	if secondIndex == -1 || thirdIndex == -1 || firstIndex == -1 {
@@ -737,6 +733,9 @@ func TestCanonicalURL(t *testing.T) {
 		{"posts.html", "https://letsbuild.cloud/posts.html"},
 		{"tags/aws.html", "https://letsbuild.cloud/tags/aws.html"},
 		{"/already-rooted.html", "https://letsbuild.cloud/already-rooted.html"},
+		{"2023/index.html", "https://letsbuild.cloud/2023/"},
+		{"2023/07/index.html", "https://letsbuild.cloud/2023/07/"},
+		{"notindex.html", "https://letsbuild.cloud/notindex.html"},
 	}
 	for _, tt := range tests {
 		if got := canonicalURL(tt.in); got != tt.want {
@@ -827,12 +826,12 @@ func TestPostMetadata(t *testing.T) {
 	}
 
 	wantTags := []string{"aws", "code-review", "devops"}
-	if len(meta.Tags) != len(wantTags) {
-		t.Fatalf("tags = %v, want %v", meta.Tags, wantTags)
+	if len(meta.tags()) != len(wantTags) {
+		t.Fatalf("tags = %v, want %v", meta.tags(), wantTags)
 	}
 	for i, tag := range wantTags {
-		if meta.Tags[i] != tag {
-			t.Errorf("tags = %v, want %v", meta.Tags, wantTags)
+		if meta.tags()[i] != tag {
+			t.Errorf("tags = %v, want %v", meta.tags(), wantTags)
 			break
 		}
 	}
@@ -1020,6 +1019,61 @@ func TestDatedPostsSameDateOrderIsDeterministic(t *testing.T) {
 	}
 }
 
+// TestLinkNeighbours checks older/newer links follow datedPostsNewestFirst,
+// including its filename tie-break for posts sharing a date, and that undated
+// pages stay out of the chain.
+func TestLinkNeighbours(t *testing.T) {
+	day := time.Date(2021, 12, 20, 0, 0, 0, 0, time.UTC)
+	newest := &BlogPost{Title: "Newest", OutputFile: "2022-01-01-newest.html", Date: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)}
+	robo := &BlogPost{Title: "Robo", OutputFile: "2021-12-20-robo-devops.html", Date: day}
+	cdk := &BlogPost{Title: "CDK", OutputFile: "2021-12-20-cdk-cr.html", Date: day}
+	oldest := &BlogPost{Title: "Oldest", OutputFile: "2020-01-01-old.html", Date: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	about := &BlogPost{Title: "About", OutputFile: "about.html"}
+
+	linkNeighbours([]*BlogPost{robo, about, oldest, newest, cdk})
+
+	for _, tc := range []struct {
+		post       *BlogPost
+		prev, next *BlogPost
+	}{
+		{newest, cdk, nil},
+		{cdk, robo, newest},
+		{robo, oldest, cdk},
+		{oldest, nil, robo},
+		{about, nil, nil},
+	} {
+		if tc.post.Prev != tc.prev || tc.post.Next != tc.next {
+			t.Errorf("%s: prev %v, next %v; want %v, %v", tc.post.Title, tc.post.Prev, tc.post.Next, tc.prev, tc.next)
+		}
+	}
+}
+
+// TestRenderPostNeighbours checks a post names its neighbours both to readers,
+// at the foot of the page, and to crawlers, as rel=prev/next in the head.
+func TestRenderPostNeighbours(t *testing.T) {
+	older := &BlogPost{Title: "Older <one>", OutputFile: "2023-01-01-older.html", Date: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	middle := &BlogPost{Title: "Middle", OutputFile: "2023-02-01-middle.html", Date: time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)}
+	newer := &BlogPost{Title: "Newer", OutputFile: "2023-03-01-newer.html", Date: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}
+	linkNeighbours([]*BlogPost{older, middle, newer})
+
+	page := renderPost(middle, testMetaTemplate, defaultTaxonomies())
+	assertContains(t, page,
+		`<link rel="prev" href="https://letsbuild.cloud/2023-01-01-older.html" />`,
+		`<link rel="next" href="https://letsbuild.cloud/2023-03-01-newer.html" />`,
+		`<nav class="post-nav" aria-label="More posts">`,
+		`href="/2023-01-01-older.html" rel="prev"><span>&larr; Older</span>Older &lt;one&gt;</a>`,
+		`href="/2023-03-01-newer.html" rel="next"><span>Newer &rarr;</span>Newer</a>`,
+	)
+
+	first := renderPost(older, testMetaTemplate, defaultTaxonomies())
+	assertNotContains(t, first, `rel="prev"`, "&larr; Older")
+	assertContains(t, first, `rel="next"`)
+
+	if got := renderPostNav(&BlogPost{Title: "About"}); got != "" {
+		t.Errorf("an undated page got post navigation: %q", got)
+	}
+}
+
 // TestGenerateIndexLimitsToLatest covers the homepage's "latest 5, then link to
 // the archive" rule. Every other index test uses one to three posts, so the
 // truncation and the archive link — the production path, with 15 posts live —
@@ -0,0 +1,295 @@
+package main
+
+// Minification of the build output. With minify.html or minify.css set in
+// site.yaml, the last stage of the build rewrites every HTML or CSS file in the
+// build directory — generated pages, the pages copied from static/, and
+// theme.css — without the template's indentation and the comments, and says
+// how many bytes that saved.
+//
+// It is deliberately the safe subset of minification. Whitespace is collapsed,
+// never removed between two inline elements, where a browser would show it;
+// it is dropped only beside a block, where a browser would drop it too.
+// Anything inside pre, code and textarea is left exactly as written, which
+// keeps the highlighted pre.code rows intact, and so is script.
+//
+// Line breaks stay where they were. A page keeps its line count, so what
+// `ssg check` reports at a line of a minified page is at the same line of the
+// page as the template wrote it, rather than all at line 1.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minifyPreserved are the elements whose text is copied untouched.
+var minifyPreserved = map[string]bool{"pre": true, "code": true, "textarea": true, "script": true}
+
+// minifyBlockElements are the elements whitespace beside may be dropped:
+// blocks, where a browser collapses a line's leading and trailing space
+// anyway, and the head's elements, which aren't rendered at all.
+var minifyBlockElements = map[string]bool{
+	"html": true, "head": true, "body": true, "title": true, "meta": true, "link": true, "base": true, "style": true,
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true, "dd": true, "details": true,
+	"dialog": true, "div": true, "dl": true, "dt": true, "fieldset": true, "figcaption": true, "figure": true,
+	"footer": true, "form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"header": true, "hr": true, "li": true, "main": true, "nav": true, "ol": true, "p": true, "pre": true,
+	"section": true, "summary": true, "table": true, "tbody": true, "td": true, "tfoot": true, "th": true,
+	"thead": true, "tr": true, "ul": true, "noscript": true,
+}
+
+var htmlSpaceRunRe = regexp.MustCompile(`[ \t\n\r\f]+`)
+
+// collapseSpace collapses a run of whitespace to a single space, or to its
+// line breaks when it has any.
+func collapseSpace(run string) string {
+	if n := strings.Count(run, "\n"); n > 0 {
+		return strings.Repeat("\n", n)
+	}
+	return " "
+}
+
+// minifyBoundary reports whether whitespace beside tok can go.
+func minifyBoundary(tok htmlToken) bool {
+	switch tok.Kind {
+	case htmlDoctype:
+		return true
+	case htmlStartTag, htmlEndTag:
+		return minifyBlockElements[tok.Name]
+	}
+	return false
+}
+
+// minifyHTML collapses the whitespace in src and drops its comments, other
+// than the conditional comments old browsers read.
+func minifyHTML(src string) string {
+	// Comments go first, so the text either side of one is collapsed as the
+	// one run of text it reads as. A comment leaves its line breaks behind.
+	var tokens []htmlToken
+	for _, tok := range scanHTML(src) {
+		if tok.Kind == htmlComment && !strings.HasPrefix(tok.Text, "<!--[if") {
+			tok = htmlToken{Kind: htmlText, Text: strings.Repeat("\n", strings.Count(tok.Text, "\n"))}
+		}
+		if n := len(tokens); tok.Kind == htmlText && n > 0 && tokens[n-1].Kind == htmlText {
+			tokens[n-1].Text += tok.Text
+			continue
+		}
+		tokens = append(tokens, tok)
+	}
+
+	var b strings.Builder
+	b.Grow(len(src))
+	preserved := 0 // how deep inside preserved elements the token is
+	for i, tok := range tokens {
+		raw := src[tok.Start:tok.End]
+		switch tok.Kind {
+		case htmlComment, htmlDoctype:
+			b.WriteString(raw)
+		case htmlStartTag:
+			if minifyPreserved[tok.Name] && !tok.SelfClosing {
+				preserved++
+			}
+			b.WriteString(collapseTag(raw))
+		case htmlEndTag:
+			if minifyPreserved[tok.Name] && preserved > 0 {
+				preserved--
+			}
+			b.WriteString(collapseTag(raw))
+		case htmlText:
+			var prev, next htmlToken
+			if i > 0 {
+				prev = tokens[i-1]
+			}
+			if i+1 < len(tokens) {
+				next = tokens[i+1]
+			}
+			switch {
+			case preserved > 0:
+				b.WriteString(tok.Text)
+			case prev.Kind == htmlStartTag && prev.Name == "style":
+				css := minifyCSS(tok.Text)
+				b.WriteString(css)
+				b.WriteString(strings.Repeat("\n", strings.Count(tok.Text, "\n")-strings.Count(css, "\n")))
+			default:
+				text := htmlSpaceRunRe.ReplaceAllStringFunc(tok.Text, collapseSpace)
+				if i == 0 || minifyBoundary(prev) {
+					text = strings.TrimLeft(text, " ")
+				}
+				if i+1 == len(tokens) || minifyBoundary(next) {
+					text = strings.TrimRight(text, " ")
+				}
+				b.WriteString(text)
+			}
+		}
+	}
+	return b.String()
+}
+
+// collapseTag collapses the whitespace between a tag's attributes, leaving
+// quoted values as they are. A run with line breaks keeps them.
+func collapseTag(raw string) string {
+	var b strings.Builder
+	var quote byte
+	space, breaks := false, 0
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case isHTMLSpace(c):
+			space = true
+			if c == '\n' {
+				breaks++
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		}
+		switch {
+		case breaks > 0:
+			b.WriteString(strings.Repeat("\n", breaks))
+		case space && c != '>':
+			b.WriteByte(' ')
+		}
+		space, breaks = false, 0
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// minifyCSS drops comments and collapses whitespace, removing it next to
+// the punctuation that needs none: braces, semicolons, commas and the child
+// combinator. A block's last semicolon goes too. Strings are copied as they
+// are.
+func minifyCSS(src string) string {
+	out := make([]byte, 0, len(src))
+	space := false
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				i = len(src)
+			} else {
+				i += end + 3
+			}
+			space = true
+			continue
+		case isHTMLSpace(c):
+			space = true
+			continue
+		}
+		if space && len(out) > 0 && !strings.ContainsRune("{};,>", rune(out[len(out)-1])) && !strings.ContainsRune("{};,>", rune(c)) {
+			out = append(out, ' ')
+		}
+		space = false
+
+		switch c {
+		case '"', '\'':
+			end := i + 1
+			for end < len(src) && src[end] != c {
+				if src[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end, len(src)-1)
+			out = append(out, src[i:end+1]...)
+			i = end
+		case '}':
+			if len(out) > 0 && out[len(out)-1] == ';' {
+				out = out[:len(out)-1]
+			}
+			out = append(out, c)
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out)
+}
+
+// minifySaving tallies one file type's sizes before and after.
+type minifySaving struct {
+	files         int
+	before, after int
+}
+
+func (s minifySaving) String() string {
+	percent := 0.0
+	if s.before > 0 {
+		percent = 100 * float64(s.before-s.after) / float64(s.before)
+	}
+	return fmt.Sprintf("%.1f KB → %.1f KB, %.1f KB (%.0f%%) saved",
+		float64(s.before)/1024, float64(s.after)/1024, float64(s.before-s.after)/1024, percent)
+}
+
+// minifyBuild minifies the HTML and CSS files in buildDir that cfg asks for,
+// in place, and reports the bytes saved by file type. The fingerprinted copies
+// in assets were minified before they were hashed, and are left alone.
+func minifyBuild(buildDir string, cfg minifyConfig, assets assetMap) error {
+	minifiers := map[string]func(string) string{}
+	if cfg.HTML {
+		minifiers[".html"] = minifyHTML
+	}
+	if cfg.CSS {
+		minifiers[".css"] = minifyCSS
+	}
+	if len(minifiers) == 0 {
+		return nil
+	}
+
+	hashed := assets.hashedPaths()
+	savings := make(map[string]*minifySaving)
+	err := filepath.WalkDir(buildDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := filepath.Ext(path)
+		minify := minifiers[ext]
+		if minify == nil {
+			return nil
+		}
+		if rel, err := filepath.Rel(buildDir, path); err == nil && hashed["/"+filepath.ToSlash(rel)] {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		out := minify(string(data))
+		if savings[ext] == nil {
+			savings[ext] = &minifySaving{}
+		}
+		savings[ext].files++
+		savings[ext].before += len(data)
+		savings[ext].after += len(out)
+		if len(out) == len(data) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, []byte(out), info.Mode())
+	})
+	if err != nil {
+		return err
+	}
+
+	exts := make([]string, 0, len(savings))
+	for ext := range savings {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		s := savings[ext]
+		fmt.Printf("Minified %d %s %s: %s\n", s.files, strings.ToUpper(strings.TrimPrefix(ext, ".")), plural(s.files, "file", "files"), s)
+	}
+	return nil
+}
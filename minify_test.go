@@ -0,0 +1,122 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMinifyHTML(t *testing.T) {
+	tests := map[string]struct {
+		src, want string
+	}{
+		"indentation": {
+			"<!DOCTYPE html>\n<html>\n  <head>\n    <title>\n      A  page\n    </title>\n  </head>\n  <body>\n    <p>Some\n      text</p>\n  </body>\n</html>\n",
+			"<!DOCTYPE html>\n<html>\n<head>\n<title>\nA page\n</title>\n</head>\n<body>\n<p>Some\ntext</p>\n</body>\n</html>\n",
+		},
+		"inline space kept": {
+			"<p>\n  <a href=\"/a\">a</a>\n  <em>b</em> c\n</p>",
+			"<p>\n<a href=\"/a\">a</a>\n<em>b</em> c\n</p>",
+		},
+		"comments": {
+			"<p>a <!-- note --> b</p><!--[if IE]><p>old</p><![endif]-->",
+			"<p>a b</p><!--[if IE]><p>old</p><![endif]-->",
+		},
+		"pre and code": {
+			"<div>\n  <pre class=\"code\"><div class=\"cl\"><span class=\"ln\"> 1</span><span class=\"cc\">  fn   main()</span></div>\n</pre>\n  <p>Run <code>go  build</code>  now</p>\n</div>",
+			"<div>\n<pre class=\"code\"><div class=\"cl\"><span class=\"ln\"> 1</span><span class=\"cc\">  fn   main()</span></div>\n</pre>\n<p>Run <code>go  build</code> now</p>\n</div>",
+		},
+		"script and textarea": {
+			"<script>\n  if (a  < b) {}\n</script>\n<textarea>\n  keep  this\n</textarea>",
+			"<script>\n  if (a  < b) {}\n</script>\n<textarea>\n  keep  this\n</textarea>",
+		},
+		"style": {
+			"<style>\n  /* note */\n  p > a { color: red; }\n</style>",
+			"<style>p>a{color: red}\n\n\n</style>",
+		},
+		"attributes": {
+			"<meta\n    name=\"description\"\n    content=\"Two  spaces\" />",
+			"<meta\nname=\"description\"\ncontent=\"Two  spaces\" />",
+		},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := minifyHTML(tt.src)
+			if got != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+			if again := minifyHTML(got); again != got {
+				t.Errorf("not idempotent:\n%q\n%q", got, again)
+			}
+			if strings.Count(got, "\n") != strings.Count(tt.src, "\n") {
+				t.Errorf("%d lines, want the %d the page had", strings.Count(got, "\n"), strings.Count(tt.src, "\n"))
+			}
+		})
+	}
+}
+
+func TestMinifyCSS(t *testing.T) {
+	tests := map[string]string{
+		"/* theme */\n:root {\n  --space-2: 0.5rem;\n}\n":   ":root{--space-2: 0.5rem}",
+		".a,\n.b > .c { margin: 0 auto; }":                  ".a,.b>.c{margin: 0 auto}",
+		"p::before { content: \"/* not  a comment */\"; }":  "p::before{content: \"/* not  a comment */\"}",
+		".x :hover { width: calc(1px + 2px); }":             ".x :hover{width: calc(1px + 2px)}",
+		"@media (max-width: 600px) {\n  p { margin: 0 }\n}": "@media (max-width: 600px){p{margin: 0}}",
+	}
+	for src, want := range tests {
+		if got := minifyCSS(src); got != want {
+			t.Errorf("minifyCSS(%q) = %q, want %q", src, got, want)
+		}
+	}
+}
+
+func TestMinifyBuild(t *testing.T) {
+	buildDir := writeBuild(t, map[string]string{
+		"index.html":            "<p>\n  Hello\n</p>\n",
+		"tags/aws.html":         "<p>Already</p>",
+		"theme.css":             "p {\n  color: red;\n}\n",
+		"search.js":             "var  a = 1;\n",
+		"cards/index.png":       "\x89PNG  ",
+		"theme.0123456789.css":  "p{color: red}",
+		"vendor.1234567890.css": "a {\n  color: blue;\n}\n",
+	})
+	assets := assetMap{"/theme.css": "/theme.0123456789.css"}
+	if err := minifyBuild(buildDir, minifyConfig{HTML: true}, assets); err != nil {
+		t.Fatalf("minifyBuild: %v", err)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "index.html")); got != "<p>\nHello\n</p>\n" {
+		t.Errorf("index.html = %q", got)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "theme.css")); got != "p {\n  color: red;\n}\n" {
+		t.Errorf("theme.css minified with css off: %q", got)
+	}
+
+	if err := minifyBuild(buildDir, minifyConfig{CSS: true}, assets); err != nil {
+		t.Fatalf("minifyBuild: %v", err)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "theme.css")); got != "p{color: red}" {
+		t.Errorf("theme.css = %q", got)
+	}
+	for _, name := range []string{"search.js", "cards/index.png"} {
+		if data, err := os.ReadFile(filepath.Join(buildDir, filepath.FromSlash(name))); err != nil || len(data) == 0 {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+	if got := readFile(t, filepath.Join(buildDir, "theme.0123456789.css")); got != "p{color: red}" {
+		t.Errorf("fingerprinted theme.css was touched: %q", got)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "vendor.1234567890.css")); got != "a{color: blue}" {
+		t.Errorf("a static file named like a hashed one was not minified: %q", got)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "search.js")); got != "var  a = 1;\n" {
+		t.Errorf("search.js was touched: %q", got)
+	}
+}
+
+func TestMinifySavingString(t *testing.T) {
+	s := minifySaving{files: 2, before: 4096, after: 3072}
+	if got := s.String(); got != "4.0 KB → 3.0 KB, 1.0 KB (25%) saved" {
+		t.Errorf("got %q", got)
+	}
+}
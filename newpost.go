@@ -0,0 +1,331 @@
+package main
+
+// `ssg new` scaffolds a post: content/<today>-<slug>.md with its frontmatter
+// filled in from an archetype, a markdown file with placeholders in the
+// template's {{...}} syntax. The default archetype is built in, and
+// archetypes/default.md replaces it; any other archetypes/<name>.md is chosen
+// with -archetype <name>. Tags are folded through the registry the way the
+// build folds them, and one nobody has used before is pointed out, with the
+// nearest existing tag, before it becomes a second spelling of an old one.
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/adrg/frontmatter"
+	"gopkg.in/yaml.v2"
+)
+
+// archetypesDir holds the named archetypes.
+const archetypesDir = "archetypes"
+
+// defaultArchetype is the archetype used when archetypes/default.md doesn't
+// exist.
+const defaultArchetype = `---
+title: {{title}}
+description: What this post covers and why it's worth reading, in a sentence or two for search results.
+tags: {{tags}}
+---
+
+Opening paragraph: what this post is about and why it's worth reading.
+
+<!--more-->
+
+## A heading
+`
+
+// archetypeValues are the placeholders an archetype may use.
+type archetypeValues struct {
+	Title       string
+	Slug        string
+	Date        time.Time
+	Tags        []string
+	Series      string
+	SeriesOrder int
+}
+
+// loadArchetype reads the named archetype from dir.
+func loadArchetype(dir, name string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name+".md"))
+	if err == nil {
+		return string(data), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", err
+	}
+	if name == "default" {
+		return defaultArchetype, nil
+	}
+	names := []string{"default"}
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	for _, m := range matches {
+		if n := strings.TrimSuffix(filepath.Base(m), ".md"); n != "default" {
+			names = append(names, n)
+		}
+	}
+	return "", fmt.Errorf("no archetype %q in %s/ (have %s)", name, dir, strings.Join(names, ", "))
+}
+
+// yamlScalar writes s as a YAML scalar, quoted only if it has to be: a title
+// with a colon in it is the usual case.
+func yamlScalar(s string) string {
+	out, err := yaml.Marshal(s)
+	if err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
+
+// fillArchetype replaces an archetype's placeholders. A series placeholder
+// without a series to fill it is an error rather than an empty key.
+func fillArchetype(archetype string, v archetypeValues) (string, error) {
+	if v.Series == "" && strings.Contains(archetype, "{{series}}") {
+		return "", fmt.Errorf("this archetype is a series part: name the series with -series")
+	}
+	filled := strings.NewReplacer(
+		"{{title}}", yamlScalar(v.Title),
+		"{{slug}}", v.Slug,
+		"{{date}}", v.Date.Format("2006-01-02"),
+		"{{tags}}", strings.Join(v.Tags, " "),
+		"{{series}}", yamlScalar(v.Series),
+		"{{series_order}}", strconv.Itoa(v.SeriesOrder),
+	).Replace(archetype)
+
+	// An empty value leaves "tags: " behind; trim it to "tags:".
+	lines := strings.Split(filled, "\n")
+	for i, line := range lines {
+		if trimmed := strings.TrimRight(line, " \t"); strings.HasSuffix(trimmed, ":") {
+			lines[i] = trimmed
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// existingContent is what the posts already in content/ say about tags and
+// series, for suggestions and the next series_order.
+type existingContent struct {
+	tagCounts   map[string]int
+	seriesOrder map[string]int // series slug -> highest series_order used
+	seriesParts map[string]int // series slug -> number of parts
+}
+
+// readExistingContent reads the frontmatter of every post in contentDir. A
+// post that won't parse is skipped, as the build skips it.
+func readExistingContent(contentDir string, tags *taxonomy) (existingContent, error) {
+	existing := existingContent{tagCounts: map[string]int{}, seriesOrder: map[string]int{}, seriesParts: map[string]int{}}
+	entries, err := os.ReadDir(contentDir)
+	if err != nil {
+		return existing, err
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || (!strings.HasSuffix(name, ".md") && !strings.HasSuffix(name, ".markdown")) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(contentDir, name))
+		if err != nil {
+			return existing, err
+		}
+		var meta FrontMatter
+		if _, err := frontmatter.Parse(strings.NewReader(string(data)), &meta); err != nil {
+			continue
+		}
+		for _, tag := range tags.registry.fold(normaliseTags(paramTerms(meta.Params[tags.Name]))) {
+			existing.tagCounts[tag]++
+		}
+		if slug := slugifyTag(meta.Series); slug != "" {
+			existing.seriesParts[slug]++
+			if meta.SeriesOrder > existing.seriesOrder[slug] {
+				existing.seriesOrder[slug] = meta.SeriesOrder
+			}
+		}
+	}
+	return existing, nil
+}
+
+// nextSeriesOrder is the series_order for a new part of the named series: one
+// past the highest order, or past the part count when no part has one.
+func (e existingContent) nextSeriesOrder(series string) int {
+	slug := slugifyTag(series)
+	return max(e.seriesOrder[slug], e.seriesParts[slug]) + 1
+}
+
+// popularTags returns up to n tags, most used first.
+func (e existingContent) popularTags(n int) []string {
+	tags := make([]string, 0, len(e.tagCounts))
+	for tag := range e.tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if e.tagCounts[tags[i]] != e.tagCounts[tags[j]] {
+			return e.tagCounts[tags[i]] > e.tagCounts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > n {
+		tags = tags[:n]
+	}
+	return tags
+}
+
+// nearestTag returns the existing tag closest to tag, if one is within two
+// edits or contains it whole.
+func (e existingContent) nearestTag(tag string) string {
+	best, bestDistance := "", 3
+	for _, existing := range e.popularTags(len(e.tagCounts)) {
+		d := editDistance(tag, existing)
+		if d >= bestDistance && (strings.Contains(existing, tag) || strings.Contains(tag, existing)) {
+			d = bestDistance - 1
+		}
+		if d < bestDistance {
+			best, bestDistance = existing, d
+		}
+	}
+	return best
+}
+
+// tagNotes explains what happened to each requested tag: folded into its
+// canonical term, or new to the site.
+func tagNotes(requested []string, tags *taxonomy, existing existingContent) []string {
+	var notes []string
+	for _, tag := range normaliseTags(requested) {
+		canonical := tags.registry.canonical(tag)
+		switch {
+		case canonical != tag:
+			notes = append(notes, fmt.Sprintf("tag %q is an alias; using %q", tag, canonical))
+		case existing.tagCounts[tag] > 0 || tags.registry.info(tag) != nil:
+		default:
+			if near := existing.nearestTag(tag); near != "" {
+				notes = append(notes, fmt.Sprintf("tag %q is new; did you mean %q, on %s?", tag, near, pluralPosts(existing.tagCounts[near])))
+			} else {
+				notes = append(notes, fmt.Sprintf("tag %q is new", tag))
+			}
+		}
+	}
+	return notes
+}
+
+// splitTagFlag reads -tags, which takes commas, spaces, or both.
+func splitTagFlag(s string) []string {
+	return strings.Fields(strings.ReplaceAll(s, ",", " "))
+}
+
+// createPost writes a new post to contentDir and returns its path. It never
+// overwrites: an existing file at the path is an error.
+func createPost(contentDir, body string, v archetypeValues) (string, error) {
+	path := filepath.Join(contentDir, v.Date.Format("2006-01-02")+"-"+v.Slug+".md")
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, os.ErrExist) {
+		return "", fmt.Errorf("%s already exists; not overwriting it", path)
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.WriteString(body); err != nil {
+		f.Close()
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// runNew is `ssg new "Post title"`.
+func runNew(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	tagsFlag := fs.String("tags", "", "tags for the post, comma or space separated")
+	archetype := fs.String("archetype", "default", "the archetype to start from: archetypes/<name>.md")
+	series := fs.String("series", "", "the series the post is a part of; series_order follows the last part")
+	dateFlag := fs.String("date", "", "the post's date, as yyyy-mm-dd (default today)")
+	contentDir := fs.String("content", "content", "where to write the post")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ssg new [flags] \"Post title\"\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	title := strings.TrimSpace(strings.Join(positional, " "))
+	slug := slugifyTag(title)
+	if slug == "" {
+		fmt.Fprintln(os.Stderr, "a post needs a title with letters or digits in it")
+		fs.Usage()
+		return 2
+	}
+
+	date := time.Now()
+	if *dateFlag != "" {
+		if date, err = time.Parse("2006-01-02", *dateFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "-date %q is not yyyy-mm-dd\n", *dateFlag)
+			return 2
+		}
+	}
+
+	cfg, err := loadSiteConfig(siteConfigPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	taxonomies, err := loadTaxonomies(cfg.Taxonomies, *contentDir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	tags := &taxonomy{taxonomyConfig: taxonomyConfig{Name: "tags"}}
+	for _, t := range taxonomies {
+		if t.Name == "tags" {
+			tags = t
+		}
+	}
+	existing, err := readExistingContent(*contentDir, tags)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	requested := splitTagFlag(*tagsFlag)
+	values := archetypeValues{
+		Title:  title,
+		Slug:   slug,
+		Date:   date,
+		Tags:   tags.registry.fold(normaliseTags(requested)),
+		Series: strings.TrimSpace(*series),
+	}
+	if values.Series != "" {
+		values.SeriesOrder = existing.nextSeriesOrder(values.Series)
+	}
+
+	text, err := loadArchetype(archetypesDir, *archetype)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	body, err := fillArchetype(text, values)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "archetype %s: %v\n", *archetype, err)
+		return 2
+	}
+	path, err := createPost(*contentDir, body, values)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Created %s\n", path)
+	for _, note := range tagNotes(requested, tags, existing) {
+		fmt.Fprintln(stdout, note)
+	}
+	if len(requested) == 0 {
+		if popular := existing.popularTags(12); len(popular) > 0 {
+			fmt.Fprintf(stdout, "No tags given; the most used are: %s\n", strings.Join(popular, " "))
+		}
+	}
+	return 0
+}
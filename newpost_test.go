@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFillArchetype(t *testing.T) {
+	values := archetypeValues{
+		Title: "Why: a story", Slug: "why-a-story", Date: testDate(t, "2026-10-18"),
+		Tags: []string{"ci-cd", "go"}, Series: "Robotics DevOps", SeriesOrder: 3,
+	}
+	got, err := fillArchetype("---\ntitle: {{title}}\ndate: {{date}}\ntags: {{tags}}\nseries: {{series}}\nseries_order: {{series_order}}\n---\n{{slug}}\n", values)
+	if err != nil {
+		t.Fatalf("fillArchetype: %v", err)
+	}
+	want := "---\ntitle: 'Why: a story'\ndate: 2026-10-18\ntags: ci-cd go\nseries: Robotics DevOps\nseries_order: 3\n---\nwhy-a-story\n"
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	values.Tags = nil
+	got, _ = fillArchetype("tags: {{tags}}\nA hard break  \n", values)
+	if got != "tags:\nA hard break  \n" {
+		t.Errorf("got %q, want the empty key trimmed and the body left alone", got)
+	}
+
+	values.Series = ""
+	if _, err := fillArchetype("series: {{series}}\n", values); err == nil {
+		t.Error("expected an error for a series archetype without a series")
+	}
+}
+
+func TestLoadArchetype(t *testing.T) {
+	dir := t.TempDir()
+	if got, err := loadArchetype(dir, "default"); err != nil || got != defaultArchetype {
+		t.Errorf("default = %q, %v; want the built-in archetype", got, err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "note.md"), []byte("---\ntitle: {{title}}\n---\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := loadArchetype(dir, "note"); err != nil || !strings.Contains(got, "{{title}}") {
+		t.Errorf("note = %q, %v", got, err)
+	}
+	_, err := loadArchetype(dir, "recipe")
+	if err == nil || !strings.Contains(err.Error(), "(have default, note)") {
+		t.Errorf("err = %v, want the archetypes there are listed", err)
+	}
+}
+
+func TestExistingContentSuggestions(t *testing.T) {
+	existing := existingContent{
+		tagCounts:   map[string]int{"devops": 8, "kubernetes": 2, "aws": 5},
+		seriesOrder: map[string]int{"robotics-devops": 2},
+		seriesParts: map[string]int{"robotics-devops": 2, "unordered": 3},
+	}
+	if got := existing.popularTags(2); strings.Join(got, " ") != "devops aws" {
+		t.Errorf("popularTags = %v", got)
+	}
+	if got := existing.nextSeriesOrder("Robotics DevOps"); got != 3 {
+		t.Errorf("nextSeriesOrder = %d, want 3", got)
+	}
+	if got := existing.nextSeriesOrder("unordered"); got != 4 {
+		t.Errorf("nextSeriesOrder without orders = %d, want 4", got)
+	}
+
+	tags := testTags(termRegistry{aliases: map[string]string{"ci": "ci-cd"}})
+	notes := tagNotes([]string{"devop", "aws", "ci", "rust"}, tags, existing)
+	want := []string{
+		`tag "ci" is an alias; using "ci-cd"`,
+		`tag "devop" is new; did you mean "devops", on 8 posts?`,
+		`tag "rust" is new`,
+	}
+	if strings.Join(notes, "\n") != strings.Join(want, "\n") {
+		t.Errorf("notes:\n%s\nwant:\n%s", strings.Join(notes, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// TestArchetypesPassFrontmatterCheck scaffolds a post from each archetype and
+// checks the frontmatter check has nothing to say about it.
+func TestArchetypesPassFrontmatterCheck(t *testing.T) {
+	values := archetypeValues{
+		Title: "Shipping the thing", Slug: "shipping-the-thing", Date: testDate(t, "2026-10-18"),
+		Tags: []string{"go"}, Series: "Robotics DevOps", SeriesOrder: 3,
+	}
+	for _, name := range []string{"default", "note", "series"} {
+		t.Run(name, func(t *testing.T) {
+			archetype, err := loadArchetype(archetypesDir, name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := fillArchetype(archetype, values)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir := t.TempDir()
+			if _, err := createPost(dir, body, values); err != nil {
+				t.Fatal(err)
+			}
+			findings, err := checkFrontmatter(checkContext{contentDir: dir, cfg: defaultSiteConfig()})
+			if err != nil {
+				t.Fatalf("checkFrontmatter: %v", err)
+			}
+			for _, f := range findings {
+				t.Errorf("%s", f)
+			}
+		})
+	}
+}
+
+// TestRunNew creates a post and checks a second run with the same title
+// refuses to touch it.
+func TestRunNew(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalWd)
+
+	if err := os.Mkdir("content", 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join("content", "2023-01-01-old.md"), []byte("---\ntitle: Old\ntags: devops aws\n---\nBody.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	args := []string{"Shipping the thing", "--tags", "devop,go", "-date", "2026-10-18"}
+	if status := runNew(args, &out); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	path := filepath.Join("content", "2026-10-18-shipping-the-thing.md")
+	assertContains(t, out.String(), "Created "+path, `tag "devop" is new; did you mean "devops", on 1 post?`)
+	assertContains(t, readFile(t, path), "title: Shipping the thing\n", "tags: devop go\n", "<!--more-->")
+
+	if err := os.WriteFile(path, []byte("my draft"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if status := runNew(args, &out); status != 1 {
+		t.Errorf("status = %d for an existing file, want 1", status)
+	}
+	if got := readFile(t, path); got != "my draft" {
+		t.Errorf("existing post overwritten: %q", got)
+	}
+
+	out.Reset()
+	if status := runNew([]string{"Later", "-date", "2026-10-19"}, &out); status != 0 {
+		t.Fatalf("status = %d, want 0", status)
+	}
+	assertContains(t, out.String(), "No tags given; the most used are: aws devops")
+
+	if status := runNew([]string{"!!!"}, &out); status != 2 {
+		t.Errorf("status = %d for a title with no slug, want 2", status)
+	}
+	if status := runNew([]string{"Part", "-archetype", "missing"}, &out); status != 1 {
+		t.Errorf("status = %d for a missing archetype, want 1", status)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+// Pagination for the listing pages. posts.html and each tag page split into
+// pages of a configured size; the first page keeps the URL it always had, so
+// existing links and search results still land on the newest posts, and later
+// pages sit in a directory named after it: posts/2.html, tags/aws/2.html.
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// pagerWindow is how many page numbers the pager shows either side of the
+// current one before collapsing the rest into a gap.
+const pagerWindow = 2
+
+// paginated describes one paginated listing: where its pages live and how
+// many there are.
+type paginated struct {
+	first string // build-relative path of page 1, e.g. "posts.html"
+	size  int    // posts per page; 0 for all on one
+	total int
+}
+
+// newPaginated splits n posts into pages of size at a time. A size of zero
+// or less means no pagination: every post on one page.
+func newPaginated(first string, n, size int) paginated {
+	total := 1
+	if size > 0 && n > size {
+		total = (n + size - 1) / size
+	}
+	return paginated{first: first, size: size, total: total}
+}
+
+// path returns the build-relative path of page n, counting from 1.
+func (l paginated) path(n int) string {
+	if n <= 1 {
+		return l.first
+	}
+	return fmt.Sprintf("%s/%d.html", strings.TrimSuffix(l.first, ".html"), n)
+}
+
+// paths returns every page's path in order, for the sitemap.
+func (l paginated) paths() []string {
+	out := make([]string, 0, l.total)
+	for n := 1; n <= l.total; n++ {
+		out = append(out, l.path(n))
+	}
+	return out
+}
+
+// slice returns the posts that belong on page n.
+func (l paginated) slice(posts []*BlogPost, n int) []*BlogPost {
+	if l.total == 1 {
+		return posts
+	}
+	start := (n - 1) * l.size
+	end := start + l.size
+	if end > len(posts) {
+		end = len(posts)
+	}
+	return posts[start:end]
+}
+
+// title suffixes a listing's title with its page number after the first, so
+// each page has a distinct <title>.
+func (l paginated) title(base string, n int) string {
+	if n <= 1 {
+		return base
+	}
+	return fmt.Sprintf("%s — page %d of %d", base, n, l.total)
+}
+
+// headLinks is the rel=prev/rel=next pair for page n's <head>.
+func (l paginated) headLinks(n int) string {
+	var b strings.Builder
+	if n > 1 {
+		b.WriteString(fmt.Sprintf("<link rel=\"prev\" href=\"%s\" />", html.EscapeString(canonicalURL(l.path(n-1)))))
+	}
+	if n < l.total {
+		b.WriteString(fmt.Sprintf("<link rel=\"next\" href=\"%s\" />", html.EscapeString(canonicalURL(l.path(n+1)))))
+	}
+	return b.String()
+}
+
+// pager renders the numbered page links for page n, in the style guide's
+// .pager markup: previous and next arrows, the first and last pages, and a
+// window around the current one with gaps for what falls outside it. A
+// listing that fits on one page gets no pager.
+func (l paginated) pager(n int) string {
+	if l.total <= 1 {
+		return ""
+	}
+	link := func(page int, label, attrs string) string {
+		return fmt.Sprintf("<li><a href=\"/%s\"%s>%s</a></li>", html.EscapeString(l.path(page)), attrs, label)
+	}
+
+	var b strings.Builder
+	b.WriteString("<nav aria-label=\"Pagination\"><ul class=\"pager\">")
+	if n > 1 {
+		b.WriteString(link(n-1, "&lsaquo;", " rel=\"prev\" aria-label=\"Previous page\""))
+	}
+	gap := false
+	for page := 1; page <= l.total; page++ {
+		near := page >= n-pagerWindow && page <= n+pagerWindow
+		if page != 1 && page != l.total && !near {
+			if !gap {
+				b.WriteString("<li><span class=\"gap\">&hellip;</span></li>")
+				gap = true
+			}
+			continue
+		}
+		gap = false
+		if page == n {
+			b.WriteString(fmt.Sprintf("<li><span class=\"now\" aria-current=\"page\">%d</span></li>", page))
+			continue
+		}
+		b.WriteString(link(page, fmt.Sprint(page), ""))
+	}
+	if n < l.total {
+		b.WriteString(link(n+1, "&rsaquo;", " rel=\"next\" aria-label=\"Next page\""))
+	}
+	b.WriteString("</ul></nav>")
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// pagedPosts returns n dated posts, newest first, tagged "go".
+func pagedPosts(t *testing.T, n int) []*BlogPost {
+	t.Helper()
+	posts := make([]*BlogPost, n)
+	for i := range posts {
+		day := fmt.Sprintf("2023-01-%02d", n-i)
+		posts[i] = &BlogPost{Title: "Post " + day, OutputFile: day + "-post.html", Date: testDate(t, day), Terms: map[string][]string{"tags": {"go"}}}
+	}
+	return posts
+}
+
+func TestPaginated(t *testing.T) {
+	for _, tc := range []struct {
+		n, size, total int
+	}{
+		{0, 10, 1},
+		{10, 10, 1},
+		{11, 10, 2},
+		{25, 10, 3},
+		{25, 0, 1},
+	} {
+		if got := newPaginated("posts.html", tc.n, tc.size).total; got != tc.total {
+			t.Errorf("%d posts at %d per page: %d pages, want %d", tc.n, tc.size, got, tc.total)
+		}
+	}
+
+	pages := newPaginated("tags/aws.html", 25, 10)
+	if got := strings.Join(pages.paths(), ","); got != "tags/aws.html,tags/aws/2.html,tags/aws/3.html" {
+		t.Errorf("paths = %s", got)
+	}
+
+	posts := pagedPosts(t, 25)
+	if got := pages.slice(posts, 3); len(got) != 5 || got[0] != posts[20] {
+		t.Errorf("page 3 holds %d posts starting %v, want the last 5", len(got), got[0].Title)
+	}
+	if got := newPaginated("posts.html", 25, 0).slice(posts, 1); len(got) != 25 {
+		t.Errorf("an unpaginated listing holds %d posts, want all 25", len(got))
+	}
+}
+
+func TestPaginatedTitleAndHeadLinks(t *testing.T) {
+	pages := newPaginated("posts.html", 30, 10)
+
+	if got := pages.title("All posts", 1); got != "All posts" {
+		t.Errorf("page 1 title = %q, want it unchanged", got)
+	}
+	if got := pages.title("All posts", 2); got != "All posts — page 2 of 3" {
+		t.Errorf("page 2 title = %q", got)
+	}
+
+	if got := pages.headLinks(1); got != `<link rel="next" href="https://letsbuild.cloud/posts/2.html" />` {
+		t.Errorf("page 1 head links = %q", got)
+	}
+	assertContains(t, pages.headLinks(2),
+		`<link rel="prev" href="https://letsbuild.cloud/posts.html" />`,
+		`<link rel="next" href="https://letsbuild.cloud/posts/3.html" />`,
+	)
+	assertNotContains(t, pages.headLinks(3), `rel="next"`)
+}
+
+// TestPaginatedPager checks the pager's window: the first and last pages are
+// always shown, the current one is marked rather than linked, and runs of
+// pages beyond the window collapse to a single gap.
+func TestPaginatedPager(t *testing.T) {
+	pages := newPaginated("posts.html", 100, 10)
+
+	pager := pages.pager(5)
+	assertContains(t, pager,
+		`<ul class="pager">`,
+		`<a href="/posts/4.html" rel="prev" aria-label="Previous page">&lsaquo;</a>`,
+		`<li><a href="/posts.html">1</a></li><li><span class="gap">&hellip;</span></li><li><a href="/posts/3.html">3</a></li>`,
+		`<li><span class="now" aria-current="page">5</span></li>`,
+		`<li><a href="/posts/7.html">7</a></li><li><span class="gap">&hellip;</span></li><li><a href="/posts/10.html">10</a></li>`,
+		`<a href="/posts/6.html" rel="next" aria-label="Next page">&rsaquo;</a>`,
+	)
+	assertNotContains(t, pager, `href="/posts/2.html"`, `href="/posts/8.html"`, `href="/posts/5.html"`)
+
+	assertNotContains(t, pages.pager(1), "Previous page")
+	assertNotContains(t, pages.pager(10), "Next page")
+
+	if got := newPaginated("posts.html", 5, 10).pager(1); got != "" {
+		t.Errorf("a single page got a pager: %q", got)
+	}
+}
+
+func TestGenerateArchivePaginates(t *testing.T) {
+	buildDir := t.TempDir()
+	posts := pagedPosts(t, 5)
+
+	written, err := generateArchive(posts, testMetaTemplate, buildDir, 2)
+	if err != nil {
+		t.Fatalf("generateArchive: %v", err)
+	}
+	if got := strings.Join(written, ","); got != "posts.html,posts/2.html,posts/3.html" {
+		t.Errorf("written = %s", got)
+	}
+
+	first := readFile(t, filepath.Join(buildDir, "posts.html"))
+	assertContains(t, first, posts[0].OutputFile, posts[1].OutputFile, `<link rel="next" href="https://letsbuild.cloud/posts/2.html" />`)
+	assertNotContains(t, first, posts[2].OutputFile)
+
+	last := readFile(t, filepath.Join(buildDir, "posts", "3.html"))
+	assertContains(t, last, posts[4].OutputFile, `<title>All posts — page 3 of 3</title>`, `href="https://letsbuild.cloud/posts/3.html"`)
+	assertNotContains(t, last, posts[3].OutputFile)
+}
+
+func TestGenerateArchiveUnpaginated(t *testing.T) {
+	buildDir := t.TempDir()
+
+	written, err := generateArchive(pagedPosts(t, 5), testTemplate, buildDir, 0)
+	if err != nil {
+		t.Fatalf("generateArchive: %v", err)
+	}
+	if len(written) != 1 || written[0] != "posts.html" {
+		t.Errorf("written = %v, want only posts.html", written)
+	}
+	if _, err := os.Stat(filepath.Join(buildDir, "posts")); !os.IsNotExist(err) {
+		t.Error("an unpaginated archive created posts/")
+	}
+	assertNotContains(t, readFile(t, filepath.Join(buildDir, "posts.html")), `class="pager"`)
+}
+
+func TestGenerateTagPagesPaginates(t *testing.T) {
+	buildDir := t.TempDir()
+	cfg := defaultSiteConfig()
+	cfg.Pagination.Tags = 2
+
+	written, err := generateTaxonomyPages(testTags(termRegistry{}), pagedPosts(t, 3), testMetaTemplate, buildDir, cfg)
+	if err != nil {
+		t.Fatalf("generateTaxonomyPages: %v", err)
+	}
+	if got := strings.Join(written, ","); got != "tags/go.html,tags/go/2.html,tags.html" {
+		t.Errorf("written = %s", got)
+	}
+
+	second := readFile(t, filepath.Join(buildDir, "tags", "go", "2.html"))
+	assertContains(t, second,
+		`<link rel="prev" href="https://letsbuild.cloud/tags/go.html" />`,
+		`<span class="now" aria-current="page">2</span>`,
+		`href="/tags/go.xml"`,
+	)
+}
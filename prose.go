@@ -0,0 +1,577 @@
+package main
+
+// The prose linter: the write-good rules style.md holds the writing to, run
+// over the markdown in content/ and each post's frontmatter description. It
+// reads prose, not markup — code fences, inline code, URLs and HTML are
+// skipped, link text is kept — and reports each finding at the line it starts
+// on, under its rule and severity.
+//
+// A post can opt out where a rule is wrong about it. An HTML comment, which
+// the page never shows, ignores rules for the paragraph that follows it, or
+// with -file for the whole file; with no rules named it ignores them all:
+//
+//	<!-- prose-ignore passive adverb -->
+//	<!-- prose-ignore-file weasel -->
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severities a prose rule can be set to in site.yaml.
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+	severityOff     = "off"
+)
+
+// proseRule is one write-good rule.
+type proseRule struct {
+	name     string
+	about    string
+	severity string // the default; site.yaml can change it
+}
+
+// proseRules lists every rule, in the order style.md gives them. A doubled
+// word is nearly always a typo, so it is the one rule that fails by default.
+var proseRules = []proseRule{
+	{"passive", "passive voice", severityWarning},
+	{"illusion", "a word repeated back to back", severityError},
+	{"so", "a sentence starting with \"So\"", severityWarning},
+	{"weasel", "vague words such as \"many\" and \"very\"", severityWarning},
+	{"adverb", "adverbs that prop up a weaker verb", severityWarning},
+	{"cliche", "overused phrases", severityWarning},
+	{"repetition", "a word used three times in one sentence", severityWarning},
+	{"long-sentence", "sentences over max_sentence_words words", severityWarning},
+}
+
+// defaultMaxSentenceWords is the long-sentence limit when site.yaml sets none.
+const defaultMaxSentenceWords = 35
+
+// repetitionLimit is how many times a word of five letters or more, other than
+// a stop word, may appear in one sentence before the repetition rule flags it.
+// Twice is often the point: "custom resource" in a post about custom
+// resources.
+const repetitionLimit = 3
+
+// validateProseLint rejects rule names and severities the linter doesn't
+// know, for the same reason site.yaml is decoded strictly.
+func validateProseLint(cfg proseLintConfig) error {
+	known := make(map[string]bool, len(proseRules))
+	for _, rule := range proseRules {
+		known[rule.name] = true
+	}
+	for name, severity := range cfg.Rules {
+		if !known[name] {
+			return fmt.Errorf("lint.prose.rules: unknown rule %q", name)
+		}
+		switch severity {
+		case severityError, severityWarning, severityOff:
+		default:
+			return fmt.Errorf("lint.prose.rules.%s: severity %q is not error, warning or off", name, severity)
+		}
+	}
+	if cfg.MaxSentenceWords < 0 {
+		return fmt.Errorf("lint.prose.max_sentence_words must not be negative")
+	}
+	return nil
+}
+
+// severities resolves every rule's severity from its default and cfg.
+func (cfg proseLintConfig) severities() map[string]string {
+	out := make(map[string]string, len(proseRules))
+	for _, rule := range proseRules {
+		out[rule.name] = rule.severity
+		if s, ok := cfg.Rules[rule.name]; ok {
+			out[rule.name] = s
+		}
+	}
+	return out
+}
+
+// proseWord is one word, or one run of sentence-ending punctuation, and the
+// line it is on.
+type proseWord struct {
+	text       string
+	line       int
+	stop       bool   // ends a sentence
+	pause      bool   // punctuation, such as a dash or comma, separates it from the word before
+	source     string // the markdown of the line the word is on
+	start, end int    // the word's byte offsets in source
+}
+
+// proseBlock is a run of prose that sentences don't cross: a paragraph, a list
+// item, a heading, a description.
+type proseBlock struct {
+	words       []proseWord
+	ignore      map[string]bool // rules ignored here; "*" for every rule
+	description bool            // the frontmatter description, not the body
+}
+
+var (
+	proseIgnoreRe   = regexp.MustCompile(`^<!--\s*prose-ignore(-file)?\b([^>]*?)\s*-->$`)
+	fenceRe         = regexp.MustCompile("^\\s*(```|~~~)")
+	listItemRe      = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+`)
+	headingRe       = regexp.MustCompile(`^#{1,6}\s+`)
+	refDefinitionRe = regexp.MustCompile(`^\s*\[[^\]]+\]:\s`)
+	codeSpanRe      = regexp.MustCompile("`+[^`]*`+")
+	imageRe         = regexp.MustCompile(`!\[[^\]]*\]\([^)]*\)`)
+	linkRe          = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	urlRe           = regexp.MustCompile(`<?https?://[^\s>)]+>?`)
+	inlineTagRe     = regexp.MustCompile(`<[^>]+>`)
+	proseTokenRe    = regexp.MustCompile(`[\p{L}\p{N}]+(?:['’\-][\p{L}\p{N}]+)*|[.!?]+`)
+)
+
+// proseLine strips a line of markdown down to the text a reader reads. What
+// it strips becomes spaces, so each word keeps its offset in the markdown.
+func proseLine(line string) string {
+	blank := func(s string) string { return strings.Repeat(" ", len(s)) }
+	line = codeSpanRe.ReplaceAllStringFunc(line, blank)
+	line = imageRe.ReplaceAllStringFunc(line, blank)
+	line = linkRe.ReplaceAllStringFunc(line, func(link string) string {
+		text := linkRe.FindStringSubmatch(link)[1]
+		return " " + text + blank(link[len(text)+1:])
+	})
+	line = urlRe.ReplaceAllStringFunc(line, blank)
+	return inlineTagRe.ReplaceAllStringFunc(line, blank)
+}
+
+// appendProseWords tokenizes text, found on line, onto words. text is source
+// as proseLine reads it, or source itself, so the two line up byte for byte. A
+// full stop only ends a sentence when a space or the end of the line follows
+// it, and not after a single letter, so "site.yaml", "v1.2" and "e.g." don't.
+func appendProseWords(words []proseWord, text, source string, line int) []proseWord {
+	last := 0
+	for _, loc := range proseTokenRe.FindAllStringIndex(text, -1) {
+		tok := text[loc[0]:loc[1]]
+		pause := strings.TrimSpace(text[last:loc[0]]) != ""
+		last = loc[1]
+		if !strings.ContainsAny(tok[:1], ".!?") {
+			words = append(words, proseWord{text: tok, line: line, pause: pause, source: source, start: loc[0], end: loc[1]})
+			continue
+		}
+		if loc[1] < len(text) && text[loc[1]] != ' ' && text[loc[1]] != '\t' && !strings.ContainsRune(`"')]*_”’`, rune(text[loc[1]])) {
+			continue
+		}
+		if n := len(words); n > 0 && len([]rune(words[n-1].text)) == 1 && tok == "." && !isDigitWord(words[n-1].text) {
+			continue
+		}
+		words = append(words, proseWord{text: tok, line: line, stop: true, source: source, start: loc[0], end: loc[1]})
+	}
+	return words
+}
+
+// isDigitWord reports whether s is all digits.
+func isDigitWord(s string) bool {
+	return strings.Trim(s, "0123456789") == ""
+}
+
+// proseBlocks splits a markdown file into blocks of prose. The frontmatter
+// description is a block of its own at the line it starts on; the rest of the
+// frontmatter is skipped. It also returns the rules the file ignores
+// throughout.
+func proseBlocks(src string) ([]proseBlock, map[string]bool) {
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	fileIgnore := make(map[string]bool)
+	var blocks []proseBlock
+	var current proseBlock
+	var pending map[string]bool // ignores waiting for the next block
+
+	flush := func() {
+		if len(current.words) > 0 {
+			blocks = append(blocks, current)
+		}
+		current = proseBlock{}
+	}
+	start := func() {
+		flush()
+		current.ignore, pending = pending, nil
+	}
+
+	i := 0
+	if len(lines) > 0 && strings.TrimSpace(lines[0]) == "---" {
+		for i = 1; i < len(lines) && strings.TrimSpace(lines[i]) != "---"; i++ {
+			if !strings.HasPrefix(lines[i], "description:") {
+				continue
+			}
+			start()
+			current.description = true
+			value := strings.TrimSpace(strings.TrimPrefix(lines[i], "description:"))
+			if value == ">" || value == "|" || value == ">-" || value == "|-" {
+				value = ""
+			}
+			value = strings.Trim(value, `"'`)
+			current.words = appendProseWords(current.words, value, value, i+1)
+			for i+1 < len(lines) && strings.HasPrefix(lines[i+1], " ") {
+				i++
+				value := strings.Trim(strings.TrimSpace(lines[i]), `"'`)
+				current.words = appendProseWords(current.words, value, value, i+1)
+			}
+			flush()
+		}
+		i++
+	}
+
+	inFence, inComment, lastList := false, false, false
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case inFence:
+			if fenceRe.MatchString(line) {
+				inFence = false
+			}
+			continue
+		case fenceRe.MatchString(line):
+			flush()
+			inFence = true
+			continue
+		case inComment:
+			if strings.Contains(line, "-->") {
+				inComment = false
+			}
+			continue
+		case strings.HasPrefix(trimmed, "<!--"):
+			if m := proseIgnoreRe.FindStringSubmatch(trimmed); m != nil {
+				rules := strings.Fields(m[2])
+				if len(rules) == 0 {
+					rules = []string{"*"}
+				}
+				target := &pending
+				if m[1] != "" {
+					target = &fileIgnore
+				}
+				if *target == nil {
+					*target = make(map[string]bool)
+				}
+				for _, rule := range rules {
+					(*target)[rule] = true
+				}
+			}
+			flush()
+			inComment = !strings.Contains(trimmed, "-->")
+			continue
+		case trimmed == "":
+			flush()
+			continue
+		case (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) && len(current.words) == 0 && !lastList:
+			continue // an indented code block
+		case strings.HasPrefix(trimmed, "|") || refDefinitionRe.MatchString(line) ||
+			(strings.HasPrefix(trimmed, "<") && strings.HasSuffix(trimmed, ">")):
+			flush()
+			continue // tables, link definitions and HTML blocks aren't prose
+		}
+
+		switch {
+		case headingRe.MatchString(trimmed):
+			start()
+			heading := headingRe.ReplaceAllString(trimmed, "")
+			current.words = appendProseWords(current.words, proseLine(heading), heading, i+1)
+			flush()
+			lastList = false
+			continue
+		case listItemRe.MatchString(line):
+			start()
+			line = listItemRe.ReplaceAllString(line, "")
+			lastList = true
+		case len(current.words) == 0:
+			start()
+			lastList = lastList && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"))
+		}
+		text := strings.TrimLeft(trimmed, "> ")
+		current.words = appendProseWords(current.words, proseLine(text), text, i+1)
+	}
+	flush()
+	return blocks, fileIgnore
+}
+
+// proseSentences splits a block's words into sentences, each without its
+// closing punctuation.
+func proseSentences(words []proseWord) [][]proseWord {
+	var sentences [][]proseWord
+	begin := 0
+	for i, w := range words {
+		if w.stop {
+			if i > begin {
+				sentences = append(sentences, words[begin:i])
+			}
+			begin = i + 1
+		}
+	}
+	if begin < len(words) {
+		sentences = append(sentences, words[begin:])
+	}
+	return sentences
+}
+
+// Word lists for the rules, after write-good's.
+var (
+	toBeWords = wordSet("am are were being is been was be isn't aren't wasn't weren't")
+	// passiveGapWords may stand between a form of "to be" and its participle,
+	// as in "is often used", "was not tested" and "are being built", along
+	// with any -ly adverb.
+	passiveGapWords = wordSet("not never being also always already often still just usually rarely sometimes then now all")
+	// irregularParticiples are past participles that don't end in -ed.
+	irregularParticiples = wordSet("awoken been born beat become begun bent beset bet bid bidden bound bitten bled " +
+		"blown broken bred brought broadcast built burnt burst bought cast caught chosen clung come cost crept cut " +
+		"dealt dug dived done drawn dreamt driven drunk eaten fallen fed felt fought found fled flung flown forbidden " +
+		"forecast foregone foreseen foretold forgiven forgotten forsaken frozen gotten given gone ground grown hung " +
+		"heard hidden hit held hurt kept knelt knit known laid led leapt learnt left lent let lain lit lost made meant " +
+		"met mistaken misunderstood mown overcome overdone overtaken overthrown paid pled proven put quit read rid " +
+		"ridden rung risen run sawn said seen sought sold sent set sewn shaken shaven shorn shed shone shod shot shown " +
+		"shrunk shut sung sunk sat slept slain slid slung slit smitten sown spoken sped spent spilt spun spit split " +
+		"spread sprung stood stolen stuck stung stunk stridden struck strung striven sworn swept swollen swum swung " +
+		"taken taught torn told thought thrived thrown thrust trodden understood upheld upset woken worn woven wed " +
+		"wept wound won withheld withstood wrung written")
+	weaselWords = wordSet("many various very fairly several extremely exceedingly quite remarkably few " +
+		"surprisingly mostly largely huge tiny excellent interestingly significantly substantially clearly vast " +
+		"relatively completely literally obviously basically essentially virtually")
+	// notAdverbs end in -ly but aren't adverbs, or aren't the kind the rule is
+	// after.
+	notAdverbs = wordSet("only family reply apply supply early daily weekly monthly yearly hourly july italy ally " +
+		"rely fly belly holy ugly silly lovely friendly likely unlikely lonely costly curly jolly bully oily " +
+		"assembly anomaly butterfly jelly rally tally comply multiply imply folly emily reilly lily holly " +
+		"kelly molly sally billy gully elderly orderly timely deadly lively chilly hilly scaly wily woolly")
+	cliches = []string{
+		"a perfect storm", "all in all", "at the end of the day", "avoid like the plague", "back to square one",
+		"best of both worlds", "bite the bullet", "easier said than done", "few and far between",
+		"game changer", "game-changing", "hit the ground running", "in a nutshell", "in this day and age",
+		"last but not least", "low-hanging fruit", "low hanging fruit", "move the needle", "needle in a haystack",
+		"only time will tell", "par for the course", "silver bullet", "the bottom line", "the elephant in the room",
+		"think outside the box", "tip of the iceberg", "touch base", "when all is said and done",
+		"at this point in time", "it goes without saying", "going forward", "the lion's share", "a level playing field",
+		"paradigm shift", "double-edged sword", "reinvent the wheel", "boil the ocean", "the writing on the wall",
+	}
+)
+
+// proseFinding is one finding with its rule and resolved severity.
+type proseFinding struct {
+	finding
+	rule     string
+	severity string
+}
+
+// lintProse checks one file's prose. file names the file in findings.
+func lintProse(file, src string, cfg proseLintConfig) []proseFinding {
+	severities := cfg.severities()
+	maxWords := cfg.MaxSentenceWords
+	if maxWords == 0 {
+		maxWords = defaultMaxSentenceWords
+	}
+
+	blocks, fileIgnore := proseBlocks(src)
+	var findings []proseFinding
+	for _, block := range blocks {
+		report := func(rule string, line int, format string, args ...interface{}) {
+			severity := severities[rule]
+			if severity == severityOff || fileIgnore["*"] || fileIgnore[rule] || block.ignore["*"] || block.ignore[rule] {
+				return
+			}
+			findings = append(findings, proseFinding{
+				finding:  finding{File: file, Line: line, Message: fmt.Sprintf("%s: %s: %s", severity, rule, fmt.Sprintf(format, args...))},
+				rule:     rule,
+				severity: severity,
+			})
+		}
+
+		// Doubled words are checked across the block, so a sentence that
+		// ends "the" and a line that starts "the" are still caught.
+		for i := 1; i < len(block.words); i++ {
+			prev, w := block.words[i-1], block.words[i]
+			if !w.stop && !prev.stop && !w.pause && strings.EqualFold(prev.text, w.text) && !isDigitWord(w.text) {
+				report("illusion", w.line, "%q twice in a row", w.text+" "+w.text)
+			}
+		}
+
+		for _, sentence := range proseSentences(block.words) {
+			lower := make([]string, len(sentence))
+			for i, w := range sentence {
+				lower[i] = strings.ToLower(strings.ReplaceAll(w.text, "’", "'"))
+			}
+
+			if lower[0] == "so" {
+				report("so", sentence[0].line, "sentence starts with %q", sentence[0].text)
+			}
+			if len(sentence) > maxWords {
+				report("long-sentence", sentence[0].line, "sentence of %d words (limit %d), starting %q",
+					len(sentence), maxWords, wordsText(sentence[:5]))
+			}
+
+			uses := make(map[string]int)
+			passiveEnd := -1 // the last participle reported, so "are being built" is one finding
+			for i, w := range lower {
+				if i > passiveEnd {
+					if j := passiveAt(lower, i); j >= 0 {
+						report("passive", sentence[i].line, "%q may be passive voice", joinWords(sentence[i:j+1]))
+						passiveEnd = j
+					}
+				}
+				if weaselWords[w] {
+					report("weasel", sentence[i].line, "%q is vague", sentence[i].text)
+				}
+				if isAdverb(w) {
+					report("adverb", sentence[i].line, "%q can weaken the sentence", sentence[i].text)
+				}
+				if len(w) >= 5 && !relatedStopWords[w] && !isDigitWord(w) {
+					uses[w]++
+					if uses[w] == repetitionLimit {
+						report("repetition", sentence[i].line, "%q %d times in one sentence", sentence[i].text, repetitionLimit)
+					}
+				}
+			}
+
+			joined := " " + strings.Join(lower, " ") + " "
+			for _, cliche := range cliches {
+				if at := strings.Index(joined, " "+cliche+" "); at >= 0 {
+					word := strings.Count(joined[:at+1], " ") - 1
+					report("cliche", sentence[word].line, "%q is a cliché", cliche)
+				}
+			}
+		}
+	}
+	return findings
+}
+
+// passiveGap is how many words may stand between a form of "to be" and the
+// participle it makes passive.
+const passiveGap = 2
+
+// passiveAt returns the index of the past participle when the lowercased words
+// at i start a passive construction — a form of "to be", up to passiveGap
+// adverbs, and a past participle — or -1 when they don't.
+func passiveAt(lower []string, i int) int {
+	if !toBeWords[lower[i]] {
+		return -1
+	}
+	for j := i + 1; j < len(lower) && j <= i+1+passiveGap; j++ {
+		if isParticiple(lower[j]) {
+			return j
+		}
+		if !passiveGapWords[lower[j]] && !isAdverb(lower[j]) {
+			break
+		}
+	}
+	return -1
+}
+
+// isParticiple reports whether w reads as a past participle.
+func isParticiple(w string) bool {
+	return (strings.HasSuffix(w, "ed") && len(w) > 3) || irregularParticiples[w]
+}
+
+// isAdverb reports whether w is an -ly adverb.
+func isAdverb(w string) bool {
+	return strings.HasSuffix(w, "ly") && len(w) > 4 && !notAdverbs[w]
+}
+
+// joinWords rejoins words with single spaces.
+func joinWords(words []proseWord) string {
+	parts := make([]string, len(words))
+	for i, w := range words {
+		parts[i] = w.text
+	}
+	return strings.Join(parts, " ")
+}
+
+// sourceText is the markdown words span, as written: punctuation, code and
+// link syntax included. A span across lines takes the whole of every line but
+// its first and last, and joins them with single spaces.
+func sourceText(words []proseWord) string {
+	var parts []string
+	for i := 0; i < len(words); {
+		j := i
+		for j+1 < len(words) && words[j+1].line == words[i].line && words[j+1].source == words[i].source {
+			j++
+		}
+		start, end := words[i].start, words[j].end
+		if i > 0 {
+			start = 0
+		}
+		if j+1 < len(words) {
+			end = len(words[j].source)
+		}
+		parts = append(parts, strings.TrimSpace(words[i].source[start:end]))
+		i = j + 1
+	}
+	return strings.Join(parts, " ")
+}
+
+// wordsText rejoins the opening words of a sentence for a message.
+func wordsText(words []proseWord) string {
+	return joinWords(words) + " …"
+}
+
+// runLint is `ssg lint`. Prose is the one thing it lints so far; the subject
+// is named anyway so the command can grow.
+func runLint(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	strict := fs.Bool("strict", false, "exit non-zero on warnings as well as errors")
+	contentDir := fs.String("content", "content", "the content to lint when no files are named")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ssg lint prose [flags] [file.md ...]\n\nRules, with their default severity:\n")
+		for _, rule := range proseRules {
+			fmt.Fprintf(fs.Output(), "  %-14s %-8s %s\n", rule.name, rule.severity, rule.about)
+		}
+		fmt.Fprintf(fs.Output(), "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) == 0 || args[0] != "prose" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := loadSiteConfig(siteConfigPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	files := args[1:]
+	if len(files) == 0 {
+		for _, pattern := range []string{"*.md", "*.markdown"} {
+			matches, err := filepath.Glob(filepath.Join(*contentDir, pattern))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	errors, warnings := 0, 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		findings := lintProse(file, string(src), cfg.Lint.Prose)
+		sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+		for _, f := range findings {
+			fmt.Fprintln(stdout, f.finding)
+			if f.severity == severityError {
+				errors++
+			} else {
+				warnings++
+			}
+		}
+	}
+	fmt.Fprintf(stdout, "prose: %d %s, %d %s in %d %s\n", errors, plural(errors, "error", "errors"),
+		warnings, plural(warnings, "warning", "warnings"), len(files), plural(len(files), "file", "files"))
+
+	if errors > 0 || (*strict && warnings > 0) {
+		return 1
+	}
+	return 0
+}
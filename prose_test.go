@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// proseReport runs lintProse and returns its findings one per line.
+func proseReport(src string, cfg proseLintConfig) string {
+	var lines []string
+	for _, f := range lintProse("post.md", src, cfg) {
+		lines = append(lines, f.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestProseBlocksSkipsCode(t *testing.T) {
+	src := "---\ntitle: Very very long\ndescription:\n  A post that is written\n  in two lines.\ntags: a b\n---\n\n" +
+		"Plain `very very` text with a [very good link](https://example.com/very).\n\n" +
+		"```go\nx := very(very)\n```\n\n" +
+		"    indented very very code\n\n" +
+		"<div class=\"very\">very</div>\n\n" +
+		"- a list item\n- another\n\n# A heading\n"
+	blocks, _ := proseBlocks(src)
+
+	var got []string
+	for _, b := range blocks {
+		var words []string
+		for _, w := range b.words {
+			words = append(words, w.text)
+		}
+		got = append(got, strings.Join(words, " ")+"@"+strconv.Itoa(b.words[0].line))
+	}
+	want := []string{
+		"A post that is written in two lines .@4",
+		"Plain text with a very good link .@9",
+		"a list item@19",
+		"another@20",
+		"A heading@22",
+	}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("blocks:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestProseSentences(t *testing.T) {
+	blocks, _ := proseBlocks("Edit site.yaml, e.g. to add v1.2 support. Then build! Done?\n")
+	sentences := proseSentences(blocks[0].words)
+	if len(sentences) != 3 {
+		t.Fatalf("sentences = %v, want 3", sentences)
+	}
+	// "v1.2" is two words, but neither its full stop nor those in "site.yaml"
+	// and "e.g." ends the sentence.
+	if n := len(sentences[0]); n != 10 {
+		t.Errorf("first sentence has %d words, want 10: %v", n, sentences[0])
+	}
+}
+
+func TestLintProseRules(t *testing.T) {
+	src := strings.Join([]string{
+		"The build was written in Go and the the page is fast.",
+		"So we shipped it.",
+		"Many posts are very short.",
+		"It quickly and only builds.",
+		"At the end of the day it works.",
+		"Pipelines feed pipelines that feed other pipelines.",
+		"Learned waste — waste we forget.",
+	}, "\n\n")
+	want := strings.Join([]string{
+		`post.md:1: error: illusion: "the the" twice in a row`,
+		`post.md:1: warning: passive: "was written" may be passive voice`,
+		`post.md:3: warning: so: sentence starts with "So"`,
+		`post.md:5: warning: weasel: "Many" is vague`,
+		`post.md:5: warning: weasel: "very" is vague`,
+		`post.md:7: warning: adverb: "quickly" can weaken the sentence`,
+		`post.md:9: warning: cliche: "at the end of the day" is a cliché`,
+		`post.md:11: warning: repetition: "pipelines" 3 times in one sentence`,
+	}, "\n")
+	if got := proseReport(src, proseLintConfig{}); got != want {
+		t.Errorf("findings:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+// TestLintProsePassiveGap checks a passive construction is found with an
+// adverb, "not" or "being" between its two halves, and reported once.
+func TestLintProsePassiveGap(t *testing.T) {
+	src := strings.Join([]string{
+		"It is often used.",
+		"It was not tested.",
+		"They are being built.",
+		"It was carefully reviewed.",
+		"It is not a thing we wanted.",
+	}, "\n\n")
+	want := strings.Join([]string{
+		`post.md:1: warning: passive: "is often used" may be passive voice`,
+		`post.md:3: warning: passive: "was not tested" may be passive voice`,
+		`post.md:5: warning: passive: "are being built" may be passive voice`,
+		`post.md:7: warning: passive: "was carefully reviewed" may be passive voice`,
+		`post.md:7: warning: adverb: "carefully" can weaken the sentence`,
+	}, "\n")
+	if got := proseReport(src, proseLintConfig{}); got != want {
+		t.Errorf("findings:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestLintProseLongSentence(t *testing.T) {
+	src := "One two three four five six seven.\n"
+	assertContains(t, proseReport(src, proseLintConfig{MaxSentenceWords: 6}),
+		`post.md:1: warning: long-sentence: sentence of 7 words (limit 6), starting "One two three four five …"`)
+	if got := proseReport(src, proseLintConfig{}); got != "" {
+		t.Errorf("a seven-word sentence is under the default limit, got %s", got)
+	}
+}
+
+func TestLintProseSeveritiesAndIgnores(t *testing.T) {
+	src := "<!-- prose-ignore-file adverb -->\n\nIt was built quickly.\n\n<!-- prose-ignore -->\nSo the the end.\n\n" +
+		"<!-- prose-ignore passive -->\nIt was done. So it goes.\n\nSo it was said.\n"
+	cfg := proseLintConfig{Rules: map[string]string{"so": "error", "passive": "off"}}
+	want := strings.Join([]string{
+		`post.md:9: error: so: sentence starts with "So"`,
+		`post.md:11: error: so: sentence starts with "So"`,
+	}, "\n")
+	if got := proseReport(src, cfg); got != want {
+		t.Errorf("findings:\n%s\n\nwant:\n%s", got, want)
+	}
+}
+
+func TestValidateProseLint(t *testing.T) {
+	if err := validateProseLint(proseLintConfig{Rules: map[string]string{"adverb": "off", "illusion": "warning"}}); err != nil {
+		t.Errorf("valid config rejected: %v", err)
+	}
+	for _, cfg := range []proseLintConfig{
+		{Rules: map[string]string{"adverbs": "off"}},
+		{Rules: map[string]string{"adverb": "fatal"}},
+		{MaxSentenceWords: -1},
+	} {
+		if err := validateProseLint(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+}
+
+// TestRunLintProse checks the command's exit status: errors fail it, warnings
+// only under -strict.
+func TestRunLintProse(t *testing.T) {
+	dir := t.TempDir()
+	warn := filepath.Join(dir, "warn.md")
+	if err := os.WriteFile(warn, []byte("It runs quickly.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.md")
+	if err := os.WriteFile(broken, []byte("It runs runs.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if status := runLint([]string{"prose", "-content", dir, warn}, &out); status != 0 {
+		t.Errorf("status = %d for warnings, want 0", status)
+	}
+	assertContains(t, out.String(), warn+`:1: warning: adverb: "quickly"`, "prose: 0 errors, 1 warning in 1 file\n")
+
+	if status := runLint([]string{"prose", "-strict", warn}, &out); status != 1 {
+		t.Errorf("status = %d for warnings under -strict, want 1", status)
+	}
+	out.Reset()
+	if status := runLint([]string{"prose", "-content", dir}, &out); status != 1 {
+		t.Errorf("status = %d with an error, want 1", status)
+	}
+	assertContains(t, out.String(), "in 2 files")
+	if status := runLint([]string{"grammar"}, &out); status != 2 {
+		t.Errorf("status = %d for an unknown subject, want 2", status)
+	}
+}
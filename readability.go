@@ -0,0 +1,274 @@
+package main
+
+// The readability report. style.md asks for approachable, concise writing;
+// this puts numbers on it for each post: Flesch reading ease, average sentence
+// length, the longest sentences, how often the voice is passive, and how much
+// of the post is code. It reads prose the way the prose linter does, so the
+// two agree on what a sentence is, and site.yaml can set thresholds that fail
+// the command when a post crosses them.
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// readabilitySentence is one sentence, for the longest-sentences list.
+type readabilitySentence struct {
+	Line  int    `json:"line"`
+	Words int    `json:"words"`
+	Text  string `json:"text"`
+}
+
+// readabilityStats are one post's numbers.
+type readabilityStats struct {
+	File               string                `json:"file"`
+	Words              int                   `json:"words"`
+	Sentences          int                   `json:"sentences"`
+	AvgSentenceWords   float64               `json:"avg_sentence_words"`
+	Flesch             float64               `json:"flesch_reading_ease"`
+	PassiveRatio       float64               `json:"passive_ratio"` // share of sentences with a passive construction
+	CodeShare          float64               `json:"code_share"`    // share of all words, prose and code, that are code
+	Longest            []readabilitySentence `json:"longest"`
+	ThresholdsBreached []string              `json:"thresholds_breached,omitempty"`
+}
+
+var (
+	vowelGroupRe  = regexp.MustCompile(`[aeiouy]+`)
+	fencedBlockRe = regexp.MustCompile("(?ms)^\\s*(```|~~~).*?^\\s*(```|~~~)[ \\t]*$")
+)
+
+// syllables estimates the syllables in a word by its vowel groups, less a
+// silent final e. It is the usual heuristic, and good enough for a score that
+// is itself an estimate.
+func syllables(word string) int {
+	w := strings.ToLower(word)
+	n := len(vowelGroupRe.FindAllString(w, -1))
+	if strings.HasSuffix(w, "e") && !strings.HasSuffix(w, "le") && n > 1 {
+		n--
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// codeWordCount counts the words inside code fences and inline code spans.
+func codeWordCount(src string) int {
+	n := 0
+	for _, block := range fencedBlockRe.FindAllString(src, -1) {
+		lines := strings.Split(strings.TrimSpace(block), "\n")
+		if len(lines) > 2 {
+			n += len(strings.Fields(strings.Join(lines[1:len(lines)-1], "\n")))
+		}
+	}
+	for _, span := range codeSpanRe.FindAllString(fencedBlockRe.ReplaceAllString(src, ""), -1) {
+		n += len(strings.Fields(strings.Trim(span, "`")))
+	}
+	return n
+}
+
+// measureReadability computes a post's numbers from its markdown, keeping the
+// longest sentences.
+func measureReadability(file, src string, longest int) readabilityStats {
+	stats := readabilityStats{File: file}
+	blocks, _ := proseBlocks(src)
+
+	var sentences []readabilitySentence
+	syllableCount, passive := 0, 0
+	for _, block := range blocks {
+		if block.description {
+			continue // the summary, not the post
+		}
+		for _, sentence := range proseSentences(block.words) {
+			lower := make([]string, len(sentence))
+			for i, w := range sentence {
+				lower[i] = strings.ToLower(w.text)
+				syllableCount += syllables(w.text)
+			}
+			for i := range lower {
+				if passiveAt(lower, i) >= 0 {
+					passive++
+					break
+				}
+			}
+			stats.Words += len(sentence)
+			sentences = append(sentences, readabilitySentence{
+				Line:  sentence[0].line,
+				Words: len(sentence),
+				Text:  sourceText(sentence),
+			})
+		}
+	}
+	stats.Sentences = len(sentences)
+
+	if stats.Sentences > 0 && stats.Words > 0 {
+		words, count := float64(stats.Words), float64(stats.Sentences)
+		stats.AvgSentenceWords = round1(words / count)
+		stats.Flesch = round1(206.835 - 1.015*(words/count) - 84.6*(float64(syllableCount)/words))
+		stats.PassiveRatio = round3(float64(passive) / count)
+	}
+	if code := codeWordCount(src); code > 0 {
+		stats.CodeShare = round3(float64(code) / float64(code+stats.Words))
+	}
+
+	sort.SliceStable(sentences, func(i, j int) bool { return sentences[i].Words > sentences[j].Words })
+	if len(sentences) > longest {
+		sentences = sentences[:longest]
+	}
+	stats.Longest = sentences
+	return stats
+}
+
+func round1(f float64) float64 { return float64(int(f*10+sign(f)*0.5)) / 10 }
+func round3(f float64) float64 { return float64(int(f*1000+sign(f)*0.5)) / 1000 }
+
+func sign(f float64) float64 {
+	if f < 0 {
+		return -1
+	}
+	return 1
+}
+
+// validateReadability rejects thresholds no post could meet, which are more
+// likely typos than standards: a negative one, or a passive ratio over 1.
+func validateReadability(cfg readabilityConfig) error {
+	switch {
+	case cfg.MaxAvgSentenceWords < 0:
+		return fmt.Errorf("report.readability.max_avg_sentence_words must not be negative")
+	case cfg.MaxPassiveRatio < 0 || cfg.MaxPassiveRatio > 1:
+		return fmt.Errorf("report.readability.max_passive_ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// breaches lists the thresholds stats crosses. An unset threshold is zero and
+// never crossed.
+func (cfg readabilityConfig) breaches(stats readabilityStats) []string {
+	if stats.Sentences == 0 {
+		return nil // nothing to read, nothing to judge
+	}
+	var out []string
+	if cfg.MinFlesch != 0 && stats.Flesch < cfg.MinFlesch {
+		out = append(out, fmt.Sprintf("Flesch reading ease %.1f is under %.1f", stats.Flesch, cfg.MinFlesch))
+	}
+	if cfg.MaxAvgSentenceWords != 0 && stats.AvgSentenceWords > cfg.MaxAvgSentenceWords {
+		out = append(out, fmt.Sprintf("average sentence of %.1f words is over %.1f", stats.AvgSentenceWords, cfg.MaxAvgSentenceWords))
+	}
+	if cfg.MaxPassiveRatio != 0 && stats.PassiveRatio > cfg.MaxPassiveRatio {
+		out = append(out, fmt.Sprintf("%.1f%% of sentences passive is over %.1f%%", stats.PassiveRatio*100, cfg.MaxPassiveRatio*100))
+	}
+	return out
+}
+
+// runReport is `ssg report`. Readability is the one report so far.
+func runReport(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "write the report as JSON")
+	longest := fs.Int("longest", 3, "how many of each post's longest sentences to list")
+	contentDir := fs.String("content", "content", "the content to report on when no files are named")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: ssg report readability [flags] [file.md ...]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	args, err := parseInterspersed(fs, args)
+	if err != nil {
+		return 2
+	}
+	if len(args) == 0 || args[0] != "readability" {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := loadSiteConfig(siteConfigPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	files := args[1:]
+	if len(files) == 0 {
+		for _, pattern := range []string{"*.md", "*.markdown"} {
+			matches, err := filepath.Glob(filepath.Join(*contentDir, pattern))
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			files = append(files, matches...)
+		}
+		sort.Strings(files)
+	}
+
+	reports := make([]readabilityStats, 0, len(files))
+	breached := 0
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		stats := measureReadability(file, string(src), *longest)
+		stats.ThresholdsBreached = cfg.Report.Readability.breaches(stats)
+		if len(stats.ThresholdsBreached) > 0 {
+			breached++
+		}
+		reports = append(reports, stats)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(struct {
+			Posts []readabilityStats `json:"posts"`
+		}{reports}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	} else {
+		writeReadabilityTable(stdout, reports)
+	}
+
+	if breached > 0 {
+		fmt.Fprintf(os.Stderr, "%d %s crossed a readability threshold\n", breached, plural(breached, "post", "posts"))
+		return 1
+	}
+	return 0
+}
+
+// writeReadabilityTable writes the report for people: a row per post, then
+// each post's longest sentences, cut to fit a terminal line, then any
+// thresholds crossed.
+func writeReadabilityTable(w io.Writer, reports []readabilityStats) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "post\twords\tsentences\tavg length\tflesch\tpassive\tcode")
+	for _, r := range reports {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%.1f\t%.1f%%\t%.1f%%\n",
+			strings.TrimSuffix(filepath.Base(r.File), filepath.Ext(r.File)), r.Words, r.Sentences,
+			r.AvgSentenceWords, r.Flesch, r.PassiveRatio*100, r.CodeShare*100)
+	}
+	tw.Flush()
+
+	header := "\nLongest sentences:"
+	for _, r := range reports {
+		for _, s := range r.Longest {
+			if header != "" {
+				fmt.Fprintln(w, header)
+				header = ""
+			}
+			fmt.Fprintf(w, "%s:%d: %d words: %s\n", r.File, s.Line, s.Words, truncateRunes(s.Text, 80))
+		}
+	}
+
+	for _, r := range reports {
+		for _, b := range r.ThresholdsBreached {
+			fmt.Fprintf(w, "%s: %s\n", r.File, b)
+		}
+	}
+}
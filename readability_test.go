@@ -0,0 +1,175 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyllables(t *testing.T) {
+	tests := map[string]int{
+		"a": 1, "the": 1, "cat": 1, "make": 1, "table": 2, "reading": 2,
+		"readability": 5, "rhythm": 1, "queue": 1, "API": 2,
+	}
+	for word, want := range tests {
+		if got := syllables(word); got != want {
+			t.Errorf("syllables(%q) = %d, want %d", word, got, want)
+		}
+	}
+}
+
+func TestMeasureReadability(t *testing.T) {
+	src := "---\ntitle: A post\ndescription: A summary that is not counted at all.\n---\n\n" +
+		"The cat sat. The build was broken by a change to the pipeline config.\n\n" +
+		"Run `go test ./...` first.\n\n" +
+		"```sh\ngo build -o ssg .\n```\n"
+	stats := measureReadability("post.md", src, 2)
+
+	if stats.Sentences != 3 || stats.Words != 16 {
+		t.Errorf("sentences = %d, words = %d, want 3 and 16", stats.Sentences, stats.Words)
+	}
+	if stats.AvgSentenceWords != 5.3 {
+		t.Errorf("average sentence = %v words, want 5.3", stats.AvgSentenceWords)
+	}
+	if stats.PassiveRatio != 0.333 {
+		t.Errorf("passive ratio = %v, want 0.333", stats.PassiveRatio)
+	}
+	// Three words of inline code and five in the fence, against 16 of prose.
+	if stats.CodeShare != 0.333 {
+		t.Errorf("code share = %v, want 0.333", stats.CodeShare)
+	}
+	if stats.Flesch < 60 || stats.Flesch > 100 {
+		t.Errorf("Flesch = %v, want an easy score for short plain sentences", stats.Flesch)
+	}
+
+	if len(stats.Longest) != 2 {
+		t.Fatalf("longest = %+v, want 2", stats.Longest)
+	}
+	if l := stats.Longest[0]; l.Line != 6 || l.Words != 11 || l.Text != "The build was broken by a change to the pipeline config" {
+		t.Errorf("longest = %+v", l)
+	}
+}
+
+// TestMeasureReadabilityLongestText checks a longest sentence is quoted as
+// written, punctuation, code and links included, across its lines, and in
+// full.
+func TestMeasureReadabilityLongestText(t *testing.T) {
+	src := "First, run `go vet` — then, if it's clean, see [the docs](https://example.com)\nfor more. Short one.\n"
+	stats := measureReadability("post.md", src, 1)
+	want := "First, run `go vet` — then, if it's clean, see [the docs](https://example.com) for more"
+	if got := stats.Longest[0].Text; got != want {
+		t.Errorf("longest = %q, want %q", got, want)
+	}
+
+	// Only the table cuts it to fit a line; the JSON keeps it whole.
+	var out bytes.Buffer
+	writeReadabilityTable(&out, []readabilityStats{stats})
+	assertContains(t, out.String(), "post.md:1: 11 words: "+truncateRunes(want, 80)+"\n")
+}
+
+// TestMeasureReadabilityPassiveGap checks the passive ratio counts passives
+// with words between the verb and its participle, once per sentence.
+func TestMeasureReadabilityPassiveGap(t *testing.T) {
+	src := "It is often used. It was not tested. They are being built. It works.\n"
+	if got := measureReadability("post.md", src, 1).PassiveRatio; got != 0.75 {
+		t.Errorf("passive ratio = %v, want 0.75", got)
+	}
+}
+
+func TestMeasureReadabilityEmpty(t *testing.T) {
+	stats := measureReadability("empty.md", "---\ntitle: Nothing\n---\n", 3)
+	if stats.Sentences != 0 || stats.Flesch != 0 || len(stats.Longest) != 0 {
+		t.Errorf("stats = %+v, want zeroes", stats)
+	}
+	if b := (readabilityConfig{MinFlesch: 50}).breaches(stats); len(b) != 0 {
+		t.Errorf("breaches = %v for a post with no prose", b)
+	}
+}
+
+func TestReadabilityBreaches(t *testing.T) {
+	stats := readabilityStats{Sentences: 10, Flesch: 42, AvgSentenceWords: 22.5, PassiveRatio: 0.2}
+	cfg := readabilityConfig{MinFlesch: 50, MaxAvgSentenceWords: 20, MaxPassiveRatio: 0.1}
+	got := cfg.breaches(stats)
+	if len(got) != 3 {
+		t.Fatalf("breaches = %v, want 3", got)
+	}
+	assertContains(t, got[0]+"\n"+got[1]+"\n"+got[2],
+		"Flesch reading ease 42.0 is under 50.0",
+		"average sentence of 22.5 words is over 20.0",
+		"20.0% of sentences passive is over 10.0%")
+
+	if got := (readabilityConfig{}).breaches(stats); len(got) != 0 {
+		t.Errorf("breaches = %v with no thresholds set", got)
+	}
+}
+
+func TestValidateReadability(t *testing.T) {
+	for _, cfg := range []readabilityConfig{
+		{MaxAvgSentenceWords: -1},
+		{MaxPassiveRatio: 1.5},
+		{MaxPassiveRatio: -0.1},
+	} {
+		if err := validateReadability(cfg); err == nil {
+			t.Errorf("expected an error for %+v", cfg)
+		}
+	}
+	if err := validateReadability(readabilityConfig{MinFlesch: 60, MaxAvgSentenceWords: 20, MaxPassiveRatio: 0.1}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// TestRunReportReadability checks the table and JSON outputs, and that a
+// threshold crossed fails the command.
+func TestRunReportReadability(t *testing.T) {
+	originalWd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(originalWd)
+
+	if err := os.Mkdir("content", 0755); err != nil {
+		t.Fatal(err)
+	}
+	post := filepath.Join("content", "post.md")
+	if err := os.WriteFile(post, []byte("The cat sat on the mat. It was fed by the dog.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if status := runReport([]string{"readability"}, &out); status != 0 {
+		t.Errorf("status = %d, want 0", status)
+	}
+	assertContains(t, out.String(), "post", "Longest sentences:", post+":1: 6 words: The cat sat on the mat")
+
+	out.Reset()
+	if status := runReport([]string{"readability", "-json", post}, &out); status != 0 {
+		t.Errorf("status = %d, want 0", status)
+	}
+	var report struct {
+		Posts []readabilityStats `json:"posts"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decoding %s: %v", out.String(), err)
+	}
+	if len(report.Posts) != 1 || report.Posts[0].Sentences != 2 || report.Posts[0].PassiveRatio != 0.5 {
+		t.Errorf("report = %+v", report)
+	}
+
+	if err := os.WriteFile(siteConfigPath, []byte("report:\n  readability:\n    max_passive_ratio: 0.25\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if status := runReport([]string{"readability"}, &out); status != 1 {
+		t.Errorf("status = %d with a threshold crossed, want 1", status)
+	}
+	assertContains(t, out.String(), post+": 50.0% of sentences passive is over 25.0%")
+
+	if status := runReport([]string{"grammar"}, &out); status != 2 {
+		t.Errorf("status = %d for an unknown report, want 2", status)
+	}
+}
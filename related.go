@@ -0,0 +1,251 @@
+package main
+
+// Related posts. Every dated post ends with a short list of others worth
+// reading next, chosen at build time from two signals: the tags it shares with
+// each candidate, and how alike their words are by TF-IDF over the rendered
+// text. Tags are the author's own judgement, so they carry most of the weight;
+// the text similarity separates candidates the tags can't, and finds the ones
+// that were never tagged alike at all.
+//
+// A post can steer the list from its frontmatter. `related` pins posts to the
+// top, in the order given; `not_related` keeps posts out. Both name posts by
+// their filename without the extension, the same stem as the page URL:
+//
+//	related: 2022-06-14-robo-sim-pipe-github-actions
+//	not_related: 2023-07-20-gated-workflows 2021-12-20-cdk-cr
+
+import (
+	"html"
+	"log"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// relatedCount is how many related posts a post lists, pins included.
+const relatedCount = 3
+
+// relatedTagWeight scales the tag score against the text score. Both lie in
+// [0, 1]; at 2 a post sharing half its tags outranks any near-duplicate text
+// that shares none.
+const relatedTagWeight = 2.0
+
+// relatedMinTermLength drops the shortest words, which are nearly all function
+// words ("a", "of", "is") and carry no topic.
+const relatedMinTermLength = 3
+
+// htmlTagRe strips markup from rendered HTML, leaving the text between tags.
+var htmlTagRe = regexp.MustCompile(`<[^>]*>`)
+
+// termRe splits text into terms: runs of letters and digits, so "snake_case"
+// and "CI/CD" each yield two.
+var termRe = regexp.MustCompile(`[\p{L}\p{N}]+`)
+
+// relatedStopWords are common words long enough to pass relatedMinTermLength
+// that would otherwise make every post look a little like every other.
+var relatedStopWords = map[string]bool{
+	"and": true, "are": true, "but": true, "can": true, "for": true, "from": true,
+	"has": true, "have": true, "how": true, "into": true, "its": true, "not": true,
+	"our": true, "that": true, "the": true, "their": true, "then": true, "there": true,
+	"these": true, "they": true, "this": true, "was": true, "what": true, "when": true,
+	"which": true, "will": true, "with": true, "you": true, "your": true,
+}
+
+// termWeight is one term's TF-IDF weight in a post's vector.
+type termWeight struct {
+	term   string
+	weight float64
+}
+
+// postStem is the name frontmatter uses for a post: its filename without the
+// extension. Pins may also be written with .md or .html, or with a leading
+// slash as copied from a link; all of them reduce to the stem.
+func postStem(name string) string {
+	name = strings.TrimPrefix(strings.TrimSpace(name), "/")
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// postTerms tokenises a post's rendered body into lowercase terms.
+func postTerms(post *BlogPost) []string {
+	text := html.UnescapeString(htmlTagRe.ReplaceAllString(post.HTML, " "))
+	var terms []string
+	for _, term := range termRe.FindAllString(strings.ToLower(text), -1) {
+		if len([]rune(term)) < relatedMinTermLength || relatedStopWords[term] {
+			continue
+		}
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// tfidfVectors builds a unit-length TF-IDF vector for each post, with terms in
+// sorted order. The order matters: floating-point addition isn't associative,
+// so summing over a map's random iteration order could change a score in its
+// last bits from one build to the next and, on a near tie, the ranking with it.
+func tfidfVectors(posts []*BlogPost) map[*BlogPost][]termWeight {
+	counts := make(map[*BlogPost]map[string]int, len(posts))
+	docFreq := make(map[string]int)
+	for _, post := range posts {
+		c := make(map[string]int)
+		for _, term := range postTerms(post) {
+			if c[term] == 0 {
+				docFreq[term]++
+			}
+			c[term]++
+		}
+		counts[post] = c
+	}
+
+	n := float64(len(posts))
+	vectors := make(map[*BlogPost][]termWeight, len(posts))
+	for _, post := range posts {
+		c := counts[post]
+		terms := make([]string, 0, len(c))
+		for term := range c {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+
+		vec := make([]termWeight, 0, len(terms))
+		var norm float64
+		for _, term := range terms {
+			// Smoothed IDF: a term in every post still weighs a little, and
+			// one in a single post weighs most.
+			w := float64(c[term]) * (math.Log((1+n)/(1+float64(docFreq[term]))) + 1)
+			vec = append(vec, termWeight{term, w})
+			norm += w * w
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for i := range vec {
+				vec[i].weight /= norm
+			}
+		}
+		vectors[post] = vec
+	}
+	return vectors
+}
+
+// cosine is the dot product of two unit vectors with sorted terms, walked as a
+// merge so the sum runs in a fixed order.
+func cosine(a, b []termWeight) float64 {
+	var dot float64
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i].term < b[j].term:
+			i++
+		case a[i].term > b[j].term:
+			j++
+		default:
+			dot += a[i].weight * b[j].weight
+			i++
+			j++
+		}
+	}
+	return dot
+}
+
+// tagOverlap is the Jaccard similarity of two sorted tag sets: shared tags over
+// all tags. Dividing by the union stops a post with twenty tags from looking
+// related to everything.
+func tagOverlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			shared++
+			i++
+			j++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// linkRelated sets each dated post's Related list: its pins first, then the
+// best-scoring other posts up to relatedCount. Candidates are the dated posts;
+// undated pages such as about.html are neither given a list nor appear in one.
+// Ties in score fall back to the newer post, then the output filename, so the
+// list is the same on every build.
+func linkRelated(posts []*BlogPost) {
+	dated := datedPostsNewestFirst(posts)
+	byStem := make(map[string]*BlogPost, len(dated))
+	for _, post := range dated {
+		byStem[postStem(post.OutputFile)] = post
+	}
+	vectors := tfidfVectors(dated)
+
+	for _, post := range dated {
+		post.Related = nil
+		skip := map[*BlogPost]bool{post: true}
+		for _, name := range post.NotRelated {
+			if other, ok := byStem[postStem(name)]; ok {
+				skip[other] = true
+			} else {
+				log.Printf("warning: %s: not_related names unknown post %q", post.Filename, name)
+			}
+		}
+
+		for _, name := range post.RelatedPins {
+			other, ok := byStem[postStem(name)]
+			if !ok {
+				log.Printf("warning: %s: related names unknown post %q", post.Filename, name)
+				continue
+			}
+			if skip[other] || len(post.Related) == relatedCount {
+				continue
+			}
+			post.Related = append(post.Related, other)
+			skip[other] = true
+		}
+
+		type candidate struct {
+			post  *BlogPost
+			score float64
+		}
+		var candidates []candidate
+		for _, other := range dated {
+			if skip[other] {
+				continue
+			}
+			score := relatedTagWeight*tagOverlap(post.tags(), other.tags()) + cosine(vectors[post], vectors[other])
+			if score > 0 {
+				candidates = append(candidates, candidate{other, score})
+			}
+		}
+		// dated is already newest first with the filename tie-break, so a
+		// stable sort on score alone keeps that order among equal scores.
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].score > candidates[j].score
+		})
+		for _, c := range candidates {
+			if len(post.Related) == relatedCount {
+				break
+			}
+			post.Related = append(post.Related, c.post)
+		}
+	}
+}
+
+// renderRelated renders the "Related posts" block at the end of a post, in the
+// same list markup as the archive. It returns an empty string when there is
+// nothing to list.
+func renderRelated(post *BlogPost) string {
+	if len(post.Related) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("<section class=\"related\" aria-labelledby=\"related-posts\">")
+	b.WriteString("<h2 id=\"related-posts\">Related posts</h2>")
+	b.WriteString(renderPostList(post.Related, false))
+	b.WriteString("</section>")
+	return b.String()
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// relatedTestPosts is a small corpus with one clear pairing by tags (the two
+// robotics posts) and one by text alone (the two untagged posts on logging).
+func relatedTestPosts(t *testing.T) []*BlogPost {
+	t.Helper()
+	return []*BlogPost{
+		{Title: "Robo one", OutputFile: "2021-01-01-robo-one.html", Date: testDate(t, "2021-01-01"),
+			Terms: map[string][]string{"tags": {"devops", "robotics"}}, HTML: "<p>Simulating robots in a pipeline.</p>"},
+		{Title: "Robo two", OutputFile: "2021-02-01-robo-two.html", Date: testDate(t, "2021-02-01"),
+			Terms: map[string][]string{"tags": {"devops", "robotics"}}, HTML: "<p>Deploying robots to the fleet.</p>"},
+		{Title: "Logs one", OutputFile: "2021-03-01-logs-one.html", Date: testDate(t, "2021-03-01"),
+			HTML: "<p>Structured logging, log levels and log retention.</p>"},
+		{Title: "Logs two", OutputFile: "2021-04-01-logs-two.html", Date: testDate(t, "2021-04-01"),
+			HTML: "<p>Which log levels matter: logging errors, not noise.</p>"},
+		{Title: "Reviews", OutputFile: "2021-05-01-reviews.html", Date: testDate(t, "2021-05-01"),
+			Terms: map[string][]string{"tags": {"devops"}}, HTML: "<p>Code review etiquette.</p>"},
+	}
+}
+
+func relatedTitles(post *BlogPost) string {
+	var titles []string
+	for _, r := range post.Related {
+		titles = append(titles, r.Title)
+	}
+	return strings.Join(titles, ",")
+}
+
+func TestTagOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		a, b []string
+		want float64
+	}{
+		{[]string{"aws", "devops"}, []string{"aws", "devops"}, 1},
+		{[]string{"aws", "devops"}, []string{"devops", "robotics"}, 1.0 / 3},
+		{[]string{"aws"}, []string{"devops"}, 0},
+		{nil, []string{"devops"}, 0},
+	} {
+		if got := tagOverlap(tc.a, tc.b); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("tagOverlap(%v, %v) = %v, want %v", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+// TestLinkRelatedRanking checks shared tags rank first, shared words find posts
+// the tags miss, and a post never lists itself.
+func TestLinkRelatedRanking(t *testing.T) {
+	posts := relatedTestPosts(t)
+	linkRelated(posts)
+
+	roboOne, logsOne := posts[0], posts[2]
+	if got := relatedTitles(roboOne); !strings.HasPrefix(got, "Robo two,Reviews") {
+		t.Errorf("Robo one related = %s, want Robo two then Reviews first", got)
+	}
+	if got := relatedTitles(logsOne); !strings.HasPrefix(got, "Logs two") {
+		t.Errorf("Logs one related = %s, want Logs two first", got)
+	}
+	for _, post := range posts {
+		if len(post.Related) > relatedCount {
+			t.Errorf("%s lists %d related posts, want at most %d", post.Title, len(post.Related), relatedCount)
+		}
+		for _, r := range post.Related {
+			if r == post {
+				t.Errorf("%s lists itself as related", post.Title)
+			}
+		}
+	}
+}
+
+// TestLinkRelatedIsDeterministic checks the lists don't depend on the order
+// posts were read in.
+func TestLinkRelatedIsDeterministic(t *testing.T) {
+	forward := relatedTestPosts(t)
+	linkRelated(forward)
+
+	reversed := relatedTestPosts(t)
+	for i, j := 0, len(reversed)-1; i < j; i, j = i+1, j-1 {
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	linkRelated(reversed)
+
+	for i, post := range forward {
+		other := reversed[len(reversed)-1-i]
+		if relatedTitles(post) != relatedTitles(other) {
+			t.Errorf("%s: %s forward, %s reversed", post.Title, relatedTitles(post), relatedTitles(other))
+		}
+	}
+}
+
+// TestLinkRelatedPinsAndExclusions checks frontmatter pins lead the list in
+// their given order, in any of the accepted spellings, and exclusions drop a
+// post however well it scores.
+func TestLinkRelatedPinsAndExclusions(t *testing.T) {
+	posts := relatedTestPosts(t)
+	roboOne := posts[0]
+	roboOne.RelatedPins = []string{"2021-04-01-logs-two.md", "/2021-03-01-logs-one.html", "no-such-post"}
+	roboOne.NotRelated = []string{"2021-02-01-robo-two"}
+
+	linkRelated(posts)
+
+	if got := relatedTitles(roboOne); got != "Logs two,Logs one,Reviews" {
+		t.Errorf("related = %s, want the two pins in order then Reviews", got)
+	}
+}
+
+// TestLinkRelatedSkipsUndatedPages checks about.html and the like are neither
+// given a related list nor offered in one.
+func TestLinkRelatedSkipsUndatedPages(t *testing.T) {
+	posts := relatedTestPosts(t)
+	about := &BlogPost{Title: "About", OutputFile: "about.html", Terms: map[string][]string{"tags": {"devops", "robotics"}}, HTML: "<p>Robots and logging.</p>"}
+	posts = append(posts, about)
+
+	linkRelated(posts)
+
+	if about.Related != nil {
+		t.Errorf("an undated page got related posts: %s", relatedTitles(about))
+	}
+	for _, post := range posts {
+		for _, r := range post.Related {
+			if r == about {
+				t.Errorf("%s lists an undated page as related", post.Title)
+			}
+		}
+	}
+}
+
+func TestRenderRelated(t *testing.T) {
+	posts := relatedTestPosts(t)
+	linkRelated(posts)
+	linkNeighbours(posts)
+
+	block := renderRelated(posts[0])
+	assertContains(t, block,
+		`<h2 id="related-posts">Related posts</h2>`,
+		`<a href="/2021-02-01-robo-two.html">Robo two</a>`,
+	)
+	if got := renderRelated(&BlogPost{Title: "Alone"}); got != "" {
+		t.Errorf("a post with nothing related rendered %q", got)
+	}
+
+	page := renderPost(posts[0], testTemplate, defaultTaxonomies())
+	related, nav := strings.Index(page, "Related posts"), strings.Index(page, "post-nav")
+	if related < 0 || nav < 0 || related > nav {
+		t.Error("related posts should come before the older/newer links")
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+// Site search. The build writes search.json, an index of every post, and
+// search.html, a page whose script (static/search.js) fetches the index and
+// ranks matches in the browser. There is no search service to run or pay for,
+// and nothing leaves the reader's machine.
+//
+// The index is the contract between the Go and JavaScript halves, so it
+// carries a version. Bump searchIndexVersion on any change a deployed script
+// would misread; the script refuses an index whose version it doesn't know
+// rather than returning quietly wrong results from a cached copy.
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+const (
+	// searchIndexPath and searchPagePath are the build-relative outputs.
+	searchIndexPath = "search.json"
+	searchPagePath  = "search.html"
+
+	// searchIndexVersion is the index format version; static/search.js
+	// declares the one it reads as INDEX_VERSION.
+	searchIndexVersion = 2
+)
+
+// searchIndex is the whole of search.json. The body terms leave out short
+// words and stop words, as postTerms does for related posts, so the index says
+// which: the script can't require a query word to match a body it was never
+// indexed from.
+type searchIndex struct {
+	Version       int              `json:"version"`
+	MinTermLength int              `json:"min_term_length"`
+	StopWords     []string         `json:"stop_words"`
+	Entries       []searchIndexDoc `json:"entries"`
+}
+
+// searchIndexDoc is one post in the index. The body is reduced to its distinct
+// terms, sorted and space-separated: the script matches terms, not phrases, so
+// repeats and word order would only add weight to the download.
+type searchIndexDoc struct {
+	URL         string   `json:"url"`
+	Title       string   `json:"title"`
+	Date        string   `json:"date,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Headings    []string `json:"headings,omitempty"`
+	Terms       string   `json:"terms"`
+}
+
+// buildSearchIndex indexes the dated posts newest first, the order a tie in
+// the script's ranking falls back to, followed by undated pages by filename.
+func buildSearchIndex(posts []*BlogPost) searchIndex {
+	ordered := datedPostsNewestFirst(posts)
+	var undated []*BlogPost
+	for _, post := range posts {
+		if post.Date.IsZero() {
+			undated = append(undated, post)
+		}
+	}
+	sort.Slice(undated, func(i, j int) bool { return undated[i].OutputFile < undated[j].OutputFile })
+	ordered = append(ordered, undated...)
+
+	stopWords := make([]string, 0, len(relatedStopWords))
+	for word := range relatedStopWords {
+		stopWords = append(stopWords, word)
+	}
+	sort.Strings(stopWords)
+
+	index := searchIndex{
+		Version:       searchIndexVersion,
+		MinTermLength: relatedMinTermLength,
+		StopWords:     stopWords,
+		Entries:       make([]searchIndexDoc, 0, len(ordered)),
+	}
+	for _, post := range ordered {
+		doc := searchIndexDoc{
+			URL:         "/" + post.OutputFile,
+			Title:       post.Title,
+			Tags:        post.tags(),
+			Description: post.Description,
+			Headings:    postHeadings(post.Markdown),
+			Terms:       strings.Join(distinctTerms(postTerms(post)), " "),
+		}
+		if !post.Date.IsZero() {
+			doc.Date = post.Date.Format("2006-01-02")
+		}
+		index.Entries = append(index.Entries, doc)
+	}
+	return index
+}
+
+// postHeadings returns the plain text of every heading in a post, in order.
+func postHeadings(content []byte) []string {
+	var headings []string
+	ast.WalkFunc(markdown.Parse(content, nil), func(node ast.Node, entering bool) ast.WalkStatus {
+		if h, ok := node.(*ast.Heading); ok && entering {
+			if text := inlinePlainText(h); text.len() > 0 {
+				headings = append(headings, string(text.runes))
+			}
+			return ast.SkipChildren
+		}
+		return ast.GoToNext
+	})
+	return headings
+}
+
+// distinctTerms returns terms deduplicated and sorted.
+func distinctTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := make([]string, 0, len(terms))
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			out = append(out, term)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// generateSearch writes search.json and search.html, and reports the index
+// size: it is downloaded whole on the first search, so growth should be seen.
+func generateSearch(posts []*BlogPost, template, buildDir string) error {
+	body, err := json.Marshal(buildSearchIndex(posts))
+	if err != nil {
+		return fmt.Errorf("marshalling search index: %w", err)
+	}
+	body = append(body, '\n')
+
+	indexPath := filepath.Join(buildDir, searchIndexPath)
+	if err := os.WriteFile(indexPath, body, 0644); err != nil {
+		return fmt.Errorf("writing search index: %w", err)
+	}
+
+	var content strings.Builder
+	content.WriteString("<form class=\"search\" role=\"search\" action=\"/search.html\" method=\"get\">")
+	content.WriteString("<div class=\"field\"><label for=\"search-query\">search posts</label>")
+	content.WriteString("<input type=\"search\" id=\"search-query\" name=\"q\" autocomplete=\"off\" /></div>")
+	content.WriteString("<button class=\"btn btn-primary\" type=\"submit\">search</button>")
+	content.WriteString("</form>")
+	content.WriteString("<p id=\"search-status\" role=\"status\"></p>")
+	content.WriteString("<ul class=\"post-list\" id=\"search-results\"></ul>")
+	content.WriteString("<noscript><p>Search runs in your browser and needs JavaScript. ")
+	content.WriteString("Every post is also listed on <a href=\"/posts.html\">the archive</a> and <a href=\"/tags.html\">by tag</a>.</p></noscript>")
+
+	page := renderPage(template, pageMeta{
+		Title:       "Search",
+		File:        searchPagePath,
+		Description: fmt.Sprintf("Search every post on %s.", siteName),
+		Canonical:   canonicalURL(searchPagePath),
+		HeadExtra:   fmt.Sprintf("<script src=\"/search.js\" data-index=\"/%s\" defer></script>", html.EscapeString(searchIndexPath)),
+		Content:     content.String(),
+	})
+
+	pagePath := filepath.Join(buildDir, searchPagePath)
+	if err := os.WriteFile(pagePath, []byte(page), 0644); err != nil {
+		return fmt.Errorf("writing search page: %w", err)
+	}
+	fmt.Printf("Generated search page: %s and its index, %s (%.1f KB)\n", pagePath, indexPath, float64(len(body))/1024)
+	return nil
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildSearchIndex(t *testing.T) {
+	posts := []*BlogPost{
+		{Title: "About", OutputFile: "about.html", HTML: "<p>About me.</p>"},
+		{Title: "Flow logs", OutputFile: "2023-02-09-vpc.html", Date: testDate(t, "2023-02-09"),
+			Terms: map[string][]string{"tags": {"aws"}}, Description: "Reading VPC flow logs.",
+			Markdown: []byte("## Parsing `flow` logs\n\nText.\n\n### Next steps\n"),
+			HTML:     "<p>Flow logs, flow logs and <code>boto3</code>.</p>"},
+		{Title: "Older", OutputFile: "2022-01-01-older.html", Date: testDate(t, "2022-01-01"), HTML: "<p>Older post.</p>"},
+	}
+
+	index := buildSearchIndex(posts)
+	if index.Version != searchIndexVersion {
+		t.Errorf("version = %d, want %d", index.Version, searchIndexVersion)
+	}
+	if index.MinTermLength != relatedMinTermLength || len(index.StopWords) != len(relatedStopWords) || index.StopWords[0] != "and" {
+		t.Errorf("index carries min_term_length %d and stop words %v, want postTerms' own", index.MinTermLength, index.StopWords)
+	}
+
+	var urls []string
+	for _, e := range index.Entries {
+		urls = append(urls, e.URL)
+	}
+	if got := strings.Join(urls, ","); got != "/2023-02-09-vpc.html,/2022-01-01-older.html,/about.html" {
+		t.Errorf("entry order = %s, want dated posts newest first then undated pages", got)
+	}
+
+	flow := index.Entries[0]
+	if flow.Date != "2023-02-09" || flow.Description != "Reading VPC flow logs." || len(flow.Tags) != 1 {
+		t.Errorf("entry metadata = %+v", flow)
+	}
+	if got := strings.Join(flow.Headings, "|"); got != "Parsing flow logs|Next steps" {
+		t.Errorf("headings = %q, want plain heading text in order", got)
+	}
+	if flow.Terms != "boto3 flow logs" {
+		t.Errorf("terms = %q, want distinct sorted body terms", flow.Terms)
+	}
+	if index.Entries[2].Date != "" {
+		t.Error("an undated page was given a date")
+	}
+}
+
+func TestGenerateSearch(t *testing.T) {
+	buildDir := t.TempDir()
+	posts := []*BlogPost{{Title: "Flow logs", OutputFile: "2023-02-09-vpc.html", Date: testDate(t, "2023-02-09"), HTML: "<p>Flow logs.</p>"}}
+
+	if err := generateSearch(posts, testTemplate, buildDir); err != nil {
+		t.Fatalf("generateSearch: %v", err)
+	}
+
+	var index searchIndex
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(buildDir, searchIndexPath))), &index); err != nil {
+		t.Fatalf("search.json does not parse: %v", err)
+	}
+	if index.Version != searchIndexVersion || len(index.Entries) != 1 {
+		t.Errorf("index = %+v", index)
+	}
+
+	page := readFile(t, filepath.Join(buildDir, searchPagePath))
+	assertContains(t, page,
+		`<form class="search" role="search" action="/search.html" method="get">`,
+		`<input type="search" id="search-query" name="q"`,
+		`id="search-results"`,
+		`<noscript>`,
+	)
+}
+
+// TestSearchScriptMatchesIndexVersion guards the contract between the two
+// halves: a version bump on one side alone would break search on deploy.
+func TestSearchScriptMatchesIndexVersion(t *testing.T) {
+	script, err := os.ReadFile(filepath.Join("static", "search.js"))
+	if err != nil {
+		t.Fatalf("reading search.js: %v", err)
+	}
+	want := fmt.Sprintf("var INDEX_VERSION = %d;", searchIndexVersion)
+	if !strings.Contains(string(script), want) {
+		t.Errorf("static/search.js does not declare %q", want)
+	}
+}
+
+// TestGenerateSiteSearch checks a full build writes the search outputs and
+// lists the page in the sitemap.
+func TestGenerateSiteSearch(t *testing.T) {
+	testDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Could not change to test directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	buildDir := filepath.Join(testDir, "build")
+	if err := generateSite(filepath.Join(testDir, "content"), buildDir, filepath.Join(testDir, "template.html"), nil); err != nil {
+		t.Fatalf("generateSite: %v", err)
+	}
+	for _, name := range []string{searchIndexPath, searchPagePath} {
+		if _, err := os.Stat(filepath.Join(buildDir, name)); err != nil {
+			t.Errorf("%s not written: %v", name, err)
+		}
+	}
+	assertContains(t, readFile(t, filepath.Join(buildDir, "sitemap.xml")), "https://letsbuild.cloud/search.html")
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A series is a run of posts on one topic, declared with `series: <name>` in
+// each part's frontmatter and optionally pinned in place with `series_order`.
+// Parts are grouped by the slug of the name, so "Robotics DevOps" and
+// "robotics devops" are the same series; the name shown is the one the first
+// part spells.
+
+// seriesIndexPath is the build-relative path of the page listing every series.
+const seriesIndexPath = "series.html"
+
+// seriesGroup is one series with its parts in reading order.
+type seriesGroup struct {
+	Name  string
+	Slug  string
+	Parts []*BlogPost
+}
+
+// seriesURL is the root-relative link to a series' entry on the index page.
+func seriesURL(s *seriesGroup) string {
+	return "/" + seriesIndexPath + "#" + s.Slug
+}
+
+// part returns post's 1-based position in the series, or 0 if it isn't a part.
+func (s *seriesGroup) part(post *BlogPost) int {
+	for i, p := range s.Parts {
+		if p == post {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// linkSeries groups posts by series, orders each group's parts, and points each
+// part at its group. It returns the series ordered newest first by their latest
+// part, then by name, for the index page.
+//
+// Parts with a series_order come first, in that order; parts without one follow
+// by date. Ties fall back to the output filename, so the order never rests on
+// directory order — the same total order datedPostsNewestFirst keeps.
+func linkSeries(posts []*BlogPost) []*seriesGroup {
+	bySlug := make(map[string]*seriesGroup)
+	var groups []*seriesGroup
+	for _, post := range posts {
+		slug := slugifyTag(post.SeriesName)
+		if slug == "" {
+			continue
+		}
+		group, ok := bySlug[slug]
+		if !ok {
+			group = &seriesGroup{Name: post.SeriesName, Slug: slug}
+			bySlug[slug] = group
+			groups = append(groups, group)
+		}
+		group.Parts = append(group.Parts, post)
+		post.Series = group
+	}
+
+	for _, group := range groups {
+		parts := group.Parts
+		sort.SliceStable(parts, func(i, j int) bool {
+			a, b := parts[i], parts[j]
+			if (a.SeriesOrder > 0) != (b.SeriesOrder > 0) {
+				return a.SeriesOrder > 0
+			}
+			if a.SeriesOrder != b.SeriesOrder {
+				return a.SeriesOrder < b.SeriesOrder
+			}
+			if !a.Date.Equal(b.Date) {
+				return a.Date.Before(b.Date)
+			}
+			return a.OutputFile < b.OutputFile
+		})
+		// The name comes from the first part in reading order, not in
+		// directory order.
+		group.Name = parts[0].SeriesName
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		li, lj := latestPart(groups[i]), latestPart(groups[j])
+		if !li.Equal(lj) {
+			return li.After(lj)
+		}
+		return groups[i].Slug < groups[j].Slug
+	})
+	return groups
+}
+
+// latestPart returns the date of a series' most recent part.
+func latestPart(s *seriesGroup) (latest time.Time) {
+	for _, p := range s.Parts {
+		if p.Date.After(latest) {
+			latest = p.Date
+		}
+	}
+	return latest
+}
+
+// renderSeriesBox renders the "Part N of M" card for a post in a series,
+// listing every part with the current one marked. It returns an empty string
+// for a post that isn't in one.
+func renderSeriesBox(post *BlogPost) string {
+	if post.Series == nil {
+		return ""
+	}
+	s := post.Series
+
+	var b strings.Builder
+	b.WriteString("<aside class=\"card card-foam series\" aria-label=\"Series\">")
+	b.WriteString(fmt.Sprintf("<span class=\"card-title\">Part %d of %d</span>", s.part(post), len(s.Parts)))
+	b.WriteString(fmt.Sprintf("<p>A series: <a href=\"%s\">%s</a></p>",
+		html.EscapeString(seriesURL(s)), html.EscapeString(s.Name)))
+	b.WriteString("<ol class=\"series-parts\">")
+	for _, part := range s.Parts {
+		if part == post {
+			b.WriteString(fmt.Sprintf("<li aria-current=\"page\">%s</li>", html.EscapeString(part.Title)))
+			continue
+		}
+		b.WriteString(fmt.Sprintf("<li><a href=\"/%s\">%s</a></li>",
+			html.EscapeString(part.OutputFile), html.EscapeString(part.Title)))
+	}
+	b.WriteString("</ol>")
+	b.WriteString("</aside>")
+	return b.String()
+}
+
+// renderSeriesLabel is the short "Part N of M · Name" marker listings show
+// beside a post in a series. It returns an empty string for a post that isn't.
+func renderSeriesLabel(post *BlogPost) string {
+	if post.Series == nil {
+		return ""
+	}
+	return fmt.Sprintf("<span class=\"series-label\">Part %d of %d &middot; %s</span>",
+		post.Series.part(post), len(post.Series.Parts), html.EscapeString(post.Series.Name))
+}
+
+// generateSeriesIndex writes series.html: every series, latest first, with its
+// parts in reading order under a heading the series box links to.
+func generateSeriesIndex(groups []*seriesGroup, template, buildDir string) error {
+	var body strings.Builder
+	body.WriteString("<p>Topics that run across more than one post, each listed in reading order.</p>")
+	for _, s := range groups {
+		body.WriteString(fmt.Sprintf("<h2 id=\"%s\">%s</h2>", html.EscapeString(s.Slug), html.EscapeString(s.Name)))
+		body.WriteString("<ol class=\"post-list\">")
+		for _, part := range s.Parts {
+			body.WriteString(fmt.Sprintf("<li><span class=\"num\">%d.</span><a href=\"/%s\">%s</a><p>%s</p></li>\n",
+				s.part(part), html.EscapeString(part.OutputFile), html.EscapeString(part.Title), html.EscapeString(part.Description)))
+		}
+		body.WriteString("</ol>")
+	}
+	body.WriteString("<p><a href=\"/posts.html\">&larr; All posts</a></p>")
+
+	description := fmt.Sprintf("Multi-part topics on %s — %d series, each in reading order.", siteName, len(groups))
+	items := make([]ldListItem, 0, len(groups))
+	for i, s := range groups {
+		items = append(items, ldListItem{Type: "ListItem", Position: i + 1, Name: s.Name, Item: siteURL + seriesURL(s)})
+	}
+	page := renderPage(template, pageMeta{
+		Title:       "Series",
+		File:        seriesIndexPath,
+		Description: description,
+		Canonical:   canonicalURL(seriesIndexPath),
+		HeadExtra:   collectionJSONLD("Series", description, seriesIndexPath, nil, items),
+		Content:     body.String(),
+	})
+
+	outputPath := filepath.Join(buildDir, seriesIndexPath)
+	if err := os.WriteFile(outputPath, []byte(page), 0644); err != nil {
+		return fmt.Errorf("writing series index: %w", err)
+	}
+	fmt.Printf("Generated series index: %s (%d series)\n", outputPath, len(groups))
+	return nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestLinkSeriesOrdering checks parts are ordered by series_order first, then
+// by date, and that posts outside a series are left alone.
+func TestLinkSeriesOrdering(t *testing.T) {
+	intro := &BlogPost{Title: "Intro", Date: testDate(t, "2023-05-01"), OutputFile: "intro.html", SeriesName: "Robotics DevOps", SeriesOrder: 1}
+	later := &BlogPost{Title: "Later", Date: testDate(t, "2023-01-01"), OutputFile: "later.html", SeriesName: "robotics devops"}
+	middle := &BlogPost{Title: "Middle", Date: testDate(t, "2023-09-01"), OutputFile: "middle.html", SeriesName: "Robotics DevOps", SeriesOrder: 2}
+	alone := &BlogPost{Title: "Alone", Date: testDate(t, "2023-02-01"), OutputFile: "alone.html"}
+
+	groups := linkSeries([]*BlogPost{later, middle, alone, intro})
+
+	if len(groups) != 1 {
+		t.Fatalf("got %d series, want 1 (names differing only in case are one series)", len(groups))
+	}
+	s := groups[0]
+	if s.Slug != "robotics-devops" || s.Name != "Robotics DevOps" {
+		t.Errorf("series = %q (%s), want the first part's spelling and its slug", s.Name, s.Slug)
+	}
+
+	var order []string
+	for _, p := range s.Parts {
+		order = append(order, p.Title)
+	}
+	if got := strings.Join(order, ","); got != "Intro,Middle,Later" {
+		t.Errorf("part order = %s, want Intro,Middle,Later", got)
+	}
+	if s.part(middle) != 2 || s.part(alone) != 0 {
+		t.Errorf("part(middle) = %d, part(alone) = %d; want 2 and 0", s.part(middle), s.part(alone))
+	}
+	if alone.Series != nil {
+		t.Error("a post without a series was linked to one")
+	}
+}
+
+// TestLinkSeriesGroupOrder checks the index lists the most recently extended
+// series first.
+func TestLinkSeriesGroupOrder(t *testing.T) {
+	posts := []*BlogPost{
+		{Title: "Old A", Date: testDate(t, "2021-01-01"), OutputFile: "a1.html", SeriesName: "Old"},
+		{Title: "New A", Date: testDate(t, "2022-01-01"), OutputFile: "b1.html", SeriesName: "New"},
+		{Title: "Old B", Date: testDate(t, "2021-02-01"), OutputFile: "a2.html", SeriesName: "Old"},
+	}
+
+	groups := linkSeries(posts)
+	if len(groups) != 2 || groups[0].Name != "New" || groups[1].Name != "Old" {
+		t.Errorf("series order = %+v, want New then Old", groups)
+	}
+}
+
+// TestRenderSeriesBox checks the box names the position, links every other
+// part, and marks the current one without linking it.
+func TestRenderSeriesBox(t *testing.T) {
+	one := &BlogPost{Title: "Part one", Date: testDate(t, "2023-01-01"), OutputFile: "one.html", SeriesName: "Pipelines"}
+	two := &BlogPost{Title: "Part two", Date: testDate(t, "2023-02-01"), OutputFile: "two.html", SeriesName: "Pipelines"}
+	linkSeries([]*BlogPost{one, two})
+
+	box := renderSeriesBox(two)
+	assertContains(t, box,
+		"Part 2 of 2",
+		`<a href="/series.html#pipelines">Pipelines</a>`,
+		`<li><a href="/one.html">Part one</a></li>`,
+		`<li aria-current="page">Part two</li>`,
+	)
+	assertNotContains(t, box, `href="/two.html"`)
+
+	if got := renderSeriesBox(&BlogPost{Title: "Alone"}); got != "" {
+		t.Errorf("a post outside a series got a box: %q", got)
+	}
+	if got := renderSeriesLabel(one); !strings.Contains(got, "Part 1 of 2 &middot; Pipelines") {
+		t.Errorf("series label = %q", got)
+	}
+}
+
+// TestGenerateSiteSeries runs the whole build over a two-part series and checks
+// each part's page, the index page, the listings and the sitemap.
+func TestGenerateSiteSeries(t *testing.T) {
+	testDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	contentDir := filepath.Join(testDir, "content")
+	parts := map[string]string{
+		"2023-04-01-pipes-one.md": "---\ntitle: Pipes one\nseries: Pipes\n---\nThe first part.",
+		"2023-04-02-pipes-two.md": "---\ntitle: Pipes two\nseries: Pipes\n---\nThe second part.",
+	}
+	for name, body := range parts {
+		if err := os.WriteFile(filepath.Join(contentDir, name), []byte(body), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Could not change to test directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	buildDir := filepath.Join(testDir, "build")
+	if err := generateSite(contentDir, buildDir, filepath.Join(testDir, "template.html"), nil); err != nil {
+		t.Fatalf("generateSite: %v", err)
+	}
+
+	// The first part is written before the second is parsed in directory
+	// order, so its box listing the second part proves the two-pass build.
+	first := readFile(t, filepath.Join(buildDir, "2023-04-01-pipes-one.html"))
+	assertContains(t, first, "Part 1 of 2", `<a href="/2023-04-02-pipes-two.html">Pipes two</a>`)
+
+	index := readFile(t, filepath.Join(buildDir, "series.html"))
+	assertContains(t, index, `<h2 id="pipes">Pipes</h2>`, `href="/2023-04-01-pipes-one.html"`)
+
+	archive := readFile(t, filepath.Join(buildDir, "index.html"))
+	assertContains(t, archive, "Part 2 of 2 &middot; Pipes")
+
+	sitemap := readFile(t, filepath.Join(buildDir, "sitemap.xml"))
+	assertContains(t, sitemap, "https://letsbuild.cloud/series.html")
+}
+
+// TestGenerateSiteWithoutSeries checks a site with no series writes no index.
+func TestGenerateSiteWithoutSeries(t *testing.T) {
+	testDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Could not change to test directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	buildDir := filepath.Join(testDir, "build")
+	if err := generateSite(filepath.Join(testDir, "content"), buildDir, filepath.Join(testDir, "template.html"), nil); err != nil {
+		t.Fatalf("generateSite: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(buildDir, "series.html")); !os.IsNotExist(err) {
+		t.Error("series.html was written though no post declares a series")
+	}
+}
@@ -0,0 +1,377 @@
+package main
+
+// Social cards: the image a link unfurls with in Slack, LinkedIn and the
+// rest. Every page rendered through the template names a card at
+// cards/<page>.png in its og:image and twitter:image, and once the pages are
+// written generateCards draws each one from what the page's own head says —
+// og:title, article:published_time, article:tag, og:site_name — so a card can
+// never disagree with the page it stands for, and no generator has to know
+// about cards. A card is a PNG in the site's statusline style, drawn with
+// image/png and the pixel font; drawn cards are cached by their content, so a
+// build only draws the cards of pages that changed.
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The card size is the one the large summary card and Open Graph both ask for.
+const (
+	cardWidth  = 1200
+	cardHeight = 630
+)
+
+// cardDir is the build directory the cards are written to.
+const cardDir = "cards"
+
+// cardLayoutVersion is part of every cache key; change it with the drawing
+// code so cards cached under the old layout are drawn again.
+const cardLayoutVersion = "1"
+
+// cardIdentity is the statusline's first segment, as template.html has it.
+const cardIdentity = "simon@letsbuild"
+
+// cardCachePath is where drawn cards are kept between builds, relative to the
+// working directory like the external link cache. It is a var so tests can
+// point it elsewhere.
+var cardCachePath = filepath.Join(".cache", "cards")
+
+// The card palette, from theme.css.
+var (
+	cardBackground = color.RGBA{0x28, 0x2a, 0x36, 0xff} // --color-bg
+	cardSurface    = color.RGBA{0x21, 0x22, 0x2c, 0xff} // --color-surface
+	cardOverlay    = color.RGBA{0x44, 0x47, 0x5a, 0xff} // --color-overlay
+	cardText       = color.RGBA{0xf8, 0xf8, 0xf2, 0xff} // --color-text
+	cardSubtle     = color.RGBA{0xb4, 0xbc, 0xd6, 0xff} // --color-subtle
+	cardMuted      = color.RGBA{0x62, 0x72, 0xa4, 0xff} // --color-muted
+	cardFoam       = color.RGBA{0x8b, 0xe9, 0xfd, 0xff} // --c-foam
+	cardIris       = color.RGBA{0xbd, 0x93, 0xf9, 0xff} // --c-iris
+)
+
+var cardPalette = color.Palette{cardBackground, cardSurface, cardOverlay, cardText, cardSubtle, cardMuted, cardFoam, cardIris}
+
+// socialCard is what a card shows.
+type socialCard struct {
+	Title    string
+	Date     time.Time // zero for an undated page
+	Tags     []string
+	SiteName string
+	Page     string // the page's build path, shown where the header shows the file
+}
+
+// key identifies the card's drawing: the same key, the same PNG.
+func (c socialCard) key() string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s",
+		cardLayoutVersion, c.Title, c.Date.Format("2006-01-02"), strings.Join(c.Tags, " "), c.SiteName, c.Page)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// cardPath is the build-relative path of the card for the page at pagePath.
+func cardPath(pagePath string) string {
+	if pagePath == "" || strings.HasSuffix(pagePath, "/") {
+		pagePath += "index.html"
+	}
+	return cardDir + "/" + strings.TrimSuffix(pagePath, ".html") + ".png"
+}
+
+// cardURL is the absolute URL of the card for the page served at canonical,
+// or "" for a page off the site.
+func cardURL(canonical string) string {
+	if canonical != siteURL && !strings.HasPrefix(canonical, siteURL+"/") {
+		return ""
+	}
+	return siteURL + "/" + cardPath(strings.TrimPrefix(strings.TrimPrefix(canonical, siteURL), "/"))
+}
+
+// cardCanvas draws on a card in whole font pixels.
+type cardCanvas struct {
+	*image.Paletted
+}
+
+func (c cardCanvas) fill(x0, y0, x1, y1 int, col color.Color) {
+	idx := uint8(cardPalette.Index(col))
+	for y := max(y0, 0); y < min(y1, cardHeight); y++ {
+		for x := max(x0, 0); x < min(x1, cardWidth); x++ {
+			c.SetColorIndex(x, y, idx)
+		}
+	}
+}
+
+// text draws s with its top-left at x, y, each font pixel scale wide, and
+// returns the x just past it.
+func (c cardCanvas) text(x, y, scale int, col color.Color, s string) int {
+	for _, r := range pixelFold(s) {
+		for row, bits := range pixelGlyphs[r] {
+			for col2, bit := range bits {
+				if bit == '#' {
+					px, py := x+col2*scale, y+row*scale
+					c.fill(px, py, px+scale, py+scale, col)
+				}
+			}
+		}
+		x += pixelAdvance * scale
+	}
+	return x - scale
+}
+
+// pixelTextWidth is how wide s draws at scale.
+func pixelTextWidth(s string, scale int) int {
+	n := len([]rune(pixelFold(s)))
+	if n == 0 {
+		return 0
+	}
+	return n*pixelAdvance*scale - scale
+}
+
+// fitPixelText shortens s with an ellipsis until it draws within width.
+func fitPixelText(s string, scale, width int) string {
+	s = pixelFold(s)
+	if pixelTextWidth(s, scale) <= width {
+		return s
+	}
+	runes := []rune(s)
+	for len(runes) > 0 && pixelTextWidth(string(runes)+"...", scale) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return strings.TrimRight(string(runes), " ") + "..."
+}
+
+// fitCardTags lists as many whole tags as draw within width at the bar scale,
+// and counts the rest.
+func fitCardTags(tags []string, width int) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	for n := len(tags); n > 0; n-- {
+		s := "#" + strings.Join(tags[:n], " #")
+		if n < len(tags) {
+			s += fmt.Sprintf(" +%d", len(tags)-n)
+		}
+		if pixelTextWidth(s, cardBarScale) <= width {
+			return s
+		}
+	}
+	return fitPixelText("#"+strings.Join(tags, " #"), cardBarScale, width)
+}
+
+// wrapPixelText breaks s into lines of at most perLine characters, at spaces
+// where it can and through a word only when the word alone is too long.
+func wrapPixelText(s string, perLine int) []string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(pixelFold(s)) {
+		for len(word) > perLine {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:perLine])
+			word = word[perLine:]
+		}
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= perLine:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Card layout, in card pixels.
+const (
+	cardBarHeight   = 80 // the top and bottom statuslines
+	cardBarScale    = 4  // font pixel size in the statuslines
+	cardSegmentPad  = 24 // padding either side of a segment's text
+	cardMargin      = 64 // the title's left and right margin
+	cardTitleMargin = 48 // space between the title block and each bar
+)
+
+// cardTitleScales are the title sizes tried, largest first, until the title
+// fits; at the smallest, a title that still doesn't fit is cut short.
+var cardTitleScales = []int{10, 8, 6, 5}
+
+// layoutCardTitle picks the largest scale at which title fits the title area
+// and returns its lines.
+func layoutCardTitle(title string) (int, []string) {
+	height := cardHeight - 2*cardBarHeight - 2*cardTitleMargin
+	for i, scale := range cardTitleScales {
+		perLine := (cardWidth - 2*cardMargin + scale) / (pixelAdvance * scale)
+		maxLines := height / ((pixelGlyphHeight + 3) * scale)
+		lines := wrapPixelText(title, perLine)
+		if len(lines) <= maxLines {
+			return scale, lines
+		}
+		if i == len(cardTitleScales)-1 {
+			lines = lines[:maxLines]
+			lines[maxLines-1] = fitPixelText(lines[maxLines-1]+" ...", scale, cardWidth-2*cardMargin)
+			return scale, lines
+		}
+	}
+	return cardTitleScales[0], nil
+}
+
+// drawCard renders c as a PNG.
+func drawCard(c socialCard) ([]byte, error) {
+	canvas := cardCanvas{image.NewPaletted(image.Rect(0, 0, cardWidth, cardHeight), cardPalette)}
+	canvas.fill(0, 0, cardWidth, cardHeight, cardBackground)
+	barText := (cardBarHeight - pixelCapHeight*cardBarScale) / 2
+
+	// Top bar, as the page header: identity, the file, and the date where
+	// the header says utf-8.
+	canvas.fill(0, 0, cardWidth, cardBarHeight, cardSurface)
+	x := pixelTextWidth(cardIdentity, cardBarScale) + 2*cardSegmentPad
+	canvas.fill(0, 0, x, cardBarHeight, cardFoam)
+	canvas.text(cardSegmentPad, barText, cardBarScale, cardBackground, cardIdentity)
+	right := "utf-8"
+	if !c.Date.IsZero() {
+		right = c.Date.Format("2006-01-02")
+	}
+	rightX := cardWidth - pixelTextWidth(right, cardBarScale) - 2*cardSegmentPad
+	canvas.fill(rightX, 0, cardWidth, cardBarHeight, cardOverlay)
+	canvas.text(rightX+cardSegmentPad, barText, cardBarScale, cardSubtle, right)
+	canvas.text(x+cardSegmentPad, barText, cardBarScale, cardMuted,
+		fitPixelText(c.Page, cardBarScale, rightX-x-2*cardSegmentPad))
+
+	// The title, centred in the space between the bars, with the prompt's
+	// block cursor after it.
+	scale, lines := layoutCardTitle(c.Title)
+	lineHeight := (pixelGlyphHeight + 3) * scale
+	blockHeight := len(lines)*lineHeight - 3*scale
+	y := cardBarHeight + (cardHeight-2*cardBarHeight-blockHeight)/2
+	end := cardMargin
+	for _, line := range lines {
+		end = canvas.text(cardMargin, y, scale, cardText, line)
+		y += lineHeight
+	}
+	if cursor := end + pixelAdvance*scale - scale; cursor+pixelGlyphWidth*scale <= cardWidth-cardMargin+scale {
+		y -= lineHeight
+		canvas.fill(cursor, y, cursor+pixelGlyphWidth*scale, y+pixelCapHeight*scale, cardFoam)
+	}
+
+	// Bottom bar, as the footer: the site name, then the tags.
+	top := cardHeight - cardBarHeight
+	canvas.fill(0, top, cardWidth, cardHeight, cardSurface)
+	x = pixelTextWidth(c.SiteName, cardBarScale) + 2*cardSegmentPad
+	canvas.fill(0, top, x, cardHeight, cardFoam)
+	canvas.text(cardSegmentPad, top+barText, cardBarScale, cardBackground, c.SiteName)
+	canvas.text(x+cardSegmentPad, top+barText, cardBarScale, cardIris,
+		fitCardTags(c.Tags, cardWidth-x-2*cardSegmentPad))
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+	if err := enc.Encode(&buf, canvas.Paletted); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// pageCard reads the card a built page asks for from its head. ok is false for
+// a page with no card of ours: a static page, a redirect stub.
+func pageCard(page *builtPage) (card socialCard, path string, ok bool) {
+	card.Page = page.Path
+	for _, tok := range page.Tokens {
+		if tok.Kind == htmlEndTag && tok.Name == "head" {
+			break
+		}
+		if tok.Kind != htmlStartTag || tok.Name != "meta" {
+			continue
+		}
+		property, _ := tok.attr("property")
+		content, _ := tok.attr("content")
+		switch property {
+		case "og:image":
+			if strings.HasPrefix(content, siteURL+"/"+cardDir+"/") {
+				path, ok = strings.TrimPrefix(content, siteURL+"/"), true
+			}
+		case "og:title":
+			card.Title = content
+		case "og:site_name":
+			card.SiteName = content
+		case "article:published_time":
+			if t, err := time.Parse(time.RFC3339, content); err == nil {
+				card.Date = t
+			}
+		case "article:tag":
+			card.Tags = append(card.Tags, content)
+		}
+	}
+	return card, path, ok
+}
+
+// generateCards draws the card every built page asks for, from the cache where
+// it can, and writes them into the build. Cached cards no page asked for are
+// removed, so the cache holds one build's worth.
+func generateCards(buildDir string) error {
+	pages, err := readBuiltPages(buildDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cardCachePath, 0755); err != nil {
+		return err
+	}
+
+	used := make(map[string]bool)
+	drawn, cached := 0, 0
+	for _, pagePath := range sortedPagePaths(pages) {
+		card, path, ok := pageCard(pages[pagePath])
+		if !ok {
+			continue
+		}
+		key := card.key() + ".png"
+		used[key] = true
+
+		data, err := os.ReadFile(filepath.Join(cardCachePath, key))
+		if err == nil {
+			cached++
+		} else if errors.Is(err, os.ErrNotExist) {
+			if data, err = drawCard(card); err != nil {
+				return fmt.Errorf("drawing card for %s: %w", pagePath, err)
+			}
+			if err := os.WriteFile(filepath.Join(cardCachePath, key), data, 0644); err != nil {
+				return err
+			}
+			drawn++
+		} else {
+			return err
+		}
+
+		out := filepath.Join(buildDir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(out), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(out, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	entries, err := os.ReadDir(cardCachePath)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !used[entry.Name()] {
+			os.Remove(filepath.Join(cardCachePath, entry.Name()))
+		}
+	}
+
+	fmt.Printf("Generated %d social cards: %d drawn, %d from cache\n", drawn+cached, drawn, cached)
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPixelGlyphs(t *testing.T) {
+	for r, rows := range pixelGlyphs {
+		if len(rows) < pixelCapHeight || len(rows) > pixelGlyphHeight {
+			t.Errorf("glyph %q has %d rows", r, len(rows))
+		}
+		for _, row := range rows {
+			if len(row) != pixelGlyphWidth || strings.Trim(row, "#.") != "" {
+				t.Errorf("glyph %q has row %q", r, row)
+			}
+		}
+	}
+	for r := rune(' '); r <= '~'; r++ {
+		if pixelGlyphs[r] == nil {
+			t.Errorf("no glyph for %q", r)
+		}
+	}
+	if got := pixelFold("Café — “naïve”\t✓"); got != `Cafe - "naive" ?` {
+		t.Errorf("pixelFold = %q", got)
+	}
+}
+
+func TestCardURL(t *testing.T) {
+	tests := map[string]string{
+		siteURL:                           siteURL + "/cards/index.png",
+		siteURL + "/":                     siteURL + "/cards/index.png",
+		siteURL + "/2023-01-15-post.html": siteURL + "/cards/2023-01-15-post.png",
+		siteURL + "/tags/aws.html":        siteURL + "/cards/tags/aws.png",
+		siteURL + "/series/robo/":         siteURL + "/cards/series/robo/index.png",
+		"https://example.com/post.html":   "",
+		siteURL + ".evil.com/post.html":   "",
+	}
+	for canonical, want := range tests {
+		if got := cardURL(canonical); got != want {
+			t.Errorf("cardURL(%q) = %q, want %q", canonical, got, want)
+		}
+	}
+}
+
+func TestWrapPixelText(t *testing.T) {
+	got := wrapPixelText("Creating a robotics simulation pipeline", 12)
+	want := []string{"Creating a", "robotics", "simulation", "pipeline"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	got = wrapPixelText("a supercalifragilistic word", 8)
+	want = []string{"a", "supercal", "ifragili", "stic", "word"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLayoutCardTitle(t *testing.T) {
+	if scale, lines := layoutCardTitle("Gated workflows"); scale != cardTitleScales[0] || len(lines) != 1 {
+		t.Errorf("short title: scale %d, %q", scale, lines)
+	}
+	scale, lines := layoutCardTitle(strings.Repeat("a very long title ", 30))
+	if scale != cardTitleScales[len(cardTitleScales)-1] {
+		t.Errorf("long title: scale %d, want the smallest", scale)
+	}
+	last := lines[len(lines)-1]
+	if !strings.HasSuffix(last, "...") || pixelTextWidth(last, scale) > cardWidth-2*cardMargin {
+		t.Errorf("long title's last line %q is not cut to fit", last)
+	}
+}
+
+func TestFitCardTags(t *testing.T) {
+	tags := []string{"aws", "cdk", "ci-cd", "robotics", "software-engineering", "devops"}
+	if got := fitCardTags(tags[:2], 800); got != "#aws #cdk" {
+		t.Errorf("got %q", got)
+	}
+	if got := fitCardTags(tags, 400); got != "#aws #cdk +4" {
+		t.Errorf("got %q, want whole tags and a count of the rest", got)
+	}
+	if got := fitCardTags(nil, 400); got != "" {
+		t.Errorf("no tags: got %q", got)
+	}
+}
+
+func TestDrawCard(t *testing.T) {
+	data, err := drawCard(socialCard{
+		Title: "Gated workflows", Date: testDate(t, "2023-01-15"),
+		Tags: []string{"ci-cd"}, SiteName: "LetsBuild.cloud", Page: "gated.html",
+	})
+	if err != nil {
+		t.Fatalf("drawCard: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("decoding card: %v", err)
+	}
+	if b := img.Bounds(); b.Dx() != cardWidth || b.Dy() != cardHeight {
+		t.Errorf("card is %dx%d, want %dx%d", b.Dx(), b.Dy(), cardWidth, cardHeight)
+	}
+}
+
+func TestPageCard(t *testing.T) {
+	head := `<html><head><meta property="og:title" content="Q&amp;A" />` +
+		`<meta property="og:site_name" content="LetsBuild.cloud" />` +
+		`<meta property="og:image" content="` + siteURL + `/cards/qa.png" />` +
+		`<meta property="article:published_time" content="2023-01-15T00:00:00Z" />` +
+		`<meta property="article:tag" content="aws" /><meta property="article:tag" content="go" />` +
+		`</head><body><meta property="og:title" content="Not this" /></body></html>`
+	card, path, ok := pageCard(&builtPage{Path: "qa.html", Tokens: scanHTML(head)})
+	if !ok || path != "cards/qa.png" {
+		t.Fatalf("path = %q, %v", path, ok)
+	}
+	if card.Title != "Q&A" || card.SiteName != "LetsBuild.cloud" || card.Date.Format("2006-01-02") != "2023-01-15" ||
+		strings.Join(card.Tags, " ") != "aws go" || card.Page != "qa.html" {
+		t.Errorf("card = %+v", card)
+	}
+
+	if _, _, ok := pageCard(&builtPage{Tokens: scanHTML(`<head><meta property="og:image" content="https://example.com/x.png" /></head>`)}); ok {
+		t.Error("a page with someone else's image has no card of ours")
+	}
+}
+
+// TestGenerateCards checks cards are written into the build, come from the
+// cache the second time, and that a card no page uses leaves the cache.
+func TestGenerateCards(t *testing.T) {
+	original := cardCachePath
+	cardCachePath = filepath.Join(t.TempDir(), "cards")
+	defer func() { cardCachePath = original }()
+
+	page := func(title string) string {
+		return `<html><head><meta property="og:title" content="` + title + `" />` +
+			`<meta property="og:image" content="` + siteURL + `/cards/post.png" /></head><body></body></html>`
+	}
+	buildDir := writeBuild(t, map[string]string{
+		"post.html":   page("First"),
+		"static.html": "<html><head></head><body></body></html>",
+	})
+	if err := generateCards(buildDir); err != nil {
+		t.Fatalf("generateCards: %v", err)
+	}
+	first := readFile(t, filepath.Join(buildDir, "cards", "post.png"))
+	if _, err := os.Stat(filepath.Join(buildDir, "cards", "static.png")); err == nil {
+		t.Error("a page without a card got one")
+	}
+	cached, _ := os.ReadDir(cardCachePath)
+	if len(cached) != 1 {
+		t.Fatalf("cache holds %d cards, want 1", len(cached))
+	}
+
+	// Spoil the cached card: a second build must copy it, not draw it again.
+	if err := os.WriteFile(filepath.Join(cardCachePath, cached[0].Name()), []byte("cached"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateCards(buildDir); err != nil {
+		t.Fatalf("generateCards: %v", err)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "cards", "post.png")); got != "cached" {
+		t.Error("second build drew the card again instead of using the cache")
+	}
+
+	if err := os.WriteFile(filepath.Join(buildDir, "post.html"), []byte(page("Retitled")), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := generateCards(buildDir); err != nil {
+		t.Fatalf("generateCards: %v", err)
+	}
+	if got := readFile(t, filepath.Join(buildDir, "cards", "post.png")); got == "cached" || got == first {
+		t.Error("a retitled page kept its old card")
+	}
+	if _, err := os.Stat(filepath.Join(cardCachePath, cached[0].Name())); err == nil {
+		t.Error("the old card was left in the cache")
+	}
+}
@@ -0,0 +1,169 @@
+// Client-side search for search.html. Fetches the index the build writes to
+// search.json and ranks posts against the query, all in the browser.
+//
+// Every query word must match somewhere in a post; a word matches a term that
+// starts with it, so "flow" finds "flows". Matches in the title count most,
+// then tags, headings, the description, and finally the body. The body is
+// indexed without short words and stop words, so those ("go", "and") only
+// count where they match: a post needn't have them to be found.
+(function () {
+  "use strict";
+
+  // INDEX_VERSION is the search.json format this script reads. It must match
+  // searchIndexVersion in search.go.
+  var INDEX_VERSION = 2;
+
+  var WEIGHTS = { title: 10, tags: 6, headings: 4, description: 3, terms: 1 };
+  var MAX_RESULTS = 20;
+
+  var script = document.currentScript;
+  var indexURL = (script && script.dataset.index) || "/search.json";
+  var indexPromise = null;
+
+  function tokenise(text) {
+    return (text || "").toLowerCase().match(/[\p{L}\p{N}]+/gu) || [];
+  }
+
+  // prepare turns an index entry into the per-field term lists scoring walks.
+  function prepare(entry) {
+    return {
+      entry: entry,
+      fields: {
+        title: tokenise(entry.title),
+        tags: (entry.tags || []).flatMap(tokenise),
+        headings: (entry.headings || []).flatMap(tokenise),
+        description: tokenise(entry.description),
+        terms: entry.terms ? entry.terms.split(" ") : []
+      }
+    };
+  }
+
+  function loadIndex() {
+    if (!indexPromise) {
+      indexPromise = fetch(indexURL)
+        .then(function (res) {
+          if (!res.ok) throw new Error("search index: HTTP " + res.status);
+          return res.json();
+        })
+        .then(function (index) {
+          if (index.version !== INDEX_VERSION) {
+            throw new Error("search index version " + index.version + ", expected " + INDEX_VERSION);
+          }
+          var stopWords = {};
+          (index.stop_words || []).forEach(function (word) { stopWords[word] = true; });
+          return {
+            docs: index.entries.map(prepare),
+            // indexed reports whether a body could hold word: the build drops
+            // the words this is false for.
+            indexed: function (word) {
+              return Array.from(word).length >= index.min_term_length && !stopWords[word];
+            }
+          };
+        });
+    }
+    return indexPromise;
+  }
+
+  function fieldMatches(terms, word) {
+    for (var i = 0; i < terms.length; i++) {
+      if (terms[i].lastIndexOf(word, 0) === 0) return true;
+    }
+    return false;
+  }
+
+  // score is 0 unless every query word the body could hold matches some
+  // field, and at least one word matches.
+  function score(doc, words, indexed) {
+    var total = 0;
+    for (var i = 0; i < words.length; i++) {
+      var best = 0;
+      for (var field in WEIGHTS) {
+        if (WEIGHTS[field] > best && fieldMatches(doc.fields[field], words[i])) {
+          best = WEIGHTS[field];
+        }
+      }
+      if (best === 0 && indexed(words[i])) return 0;
+      total += best;
+    }
+    return total;
+  }
+
+  function search(index, query) {
+    var words = tokenise(query);
+    if (words.length === 0) return [];
+    var hits = [];
+    index.docs.forEach(function (doc, order) {
+      var s = score(doc, words, index.indexed);
+      if (s > 0) hits.push({ doc: doc, score: s, order: order });
+    });
+    // Equal scores keep index order: newest first.
+    hits.sort(function (a, b) { return b.score - a.score || a.order - b.order; });
+    return hits.slice(0, MAX_RESULTS).map(function (hit) { return hit.doc.entry; });
+  }
+
+  function render(entries, query) {
+    var list = document.getElementById("search-results");
+    var status = document.getElementById("search-status");
+    list.textContent = "";
+    if (!query.trim()) {
+      status.textContent = "";
+      return;
+    }
+    status.textContent = entries.length === 0
+      ? "No posts match \u201c" + query + "\u201d."
+      : entries.length + (entries.length === 1 ? " post matches" : " posts match") + " \u201c" + query + "\u201d.";
+    entries.forEach(function (entry) {
+      var li = document.createElement("li");
+      if (entry.date) {
+        var date = document.createElement("span");
+        date.className = "date";
+        date.textContent = entry.date;
+        li.appendChild(date);
+      }
+      var link = document.createElement("a");
+      link.href = entry.url;
+      link.textContent = entry.title;
+      li.appendChild(link);
+      if (entry.description) {
+        var p = document.createElement("p");
+        p.textContent = entry.description;
+        li.appendChild(p);
+      }
+      list.appendChild(li);
+    });
+  }
+
+  function run(query) {
+    loadIndex()
+      .then(function (index) { render(search(index, query), query); })
+      .catch(function (err) {
+        document.getElementById("search-status").textContent = "Search is unavailable: " + err.message;
+      });
+  }
+
+  document.addEventListener("DOMContentLoaded", function () {
+    var form = document.querySelector("form.search");
+    var input = document.getElementById("search-query");
+    if (!form || !input) return;
+
+    var initial = new URLSearchParams(window.location.search).get("q") || "";
+    input.value = initial;
+    if (initial) run(initial);
+
+    var timer = null;
+    input.addEventListener("input", function () {
+      clearTimeout(timer);
+      timer = setTimeout(function () {
+        var url = new URL(window.location.href);
+        if (input.value) url.searchParams.set("q", input.value);
+        else url.searchParams.delete("q");
+        history.replaceState(null, "", url);
+        run(input.value);
+      }, 150);
+    });
+    form.addEventListener("submit", function (event) {
+      event.preventDefault();
+      run(input.value);
+    });
+  });
+})();
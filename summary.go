@@ -0,0 +1,207 @@
+package main
+
+// Post summaries. A post without a frontmatter description gets one from its
+// first real paragraph, read off the parsed markdown rather than the raw text:
+// string-stripping the source leaked link syntax, backticks and image markup
+// into the meta description and the feed, and mangled snake_case identifiers
+// into snakecase. A post can also mark a richer teaser with <!--more-->; the
+// HTML before the marker is its excerpt, shown by listings and the feed.
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+)
+
+// excerptMarkerRe matches a line that is the <!--more--> marker alone. Spacing
+// inside the comment is allowed, since editors and formatters like to add it.
+var excerptMarkerRe = regexp.MustCompile(`^[ \t]*<!--\s*more\s*-->[ \t]*\r?$`)
+
+// splitExcerpt returns the markdown before the first <!--more--> marker, and
+// whether there was one. A post without the marker has no excerpt; listings
+// fall back to its one-line description. A marker inside a fenced code block
+// is a post showing the marker, not using it.
+func splitExcerpt(content []byte) ([]byte, bool) {
+	inFence := false
+	for start := 0; start < len(content); {
+		end := bytes.IndexByte(content[start:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += start
+		}
+		line := content[start:end]
+		switch {
+		case fenceRe.Match(line):
+			inFence = !inFence
+		case !inFence && excerptMarkerRe.Match(line):
+			return content[:start], true
+		}
+		start = end + 1
+	}
+	return nil, false
+}
+
+// descriptionLimit is the longest auto description, in characters, ellipsis
+// included. Search engines cut meta descriptions at around this length.
+const descriptionLimit = 150
+
+// extractDescription returns the plain text of the first top-level paragraph
+// that has any, cut to descriptionLimit. Headings, code blocks, raw HTML and
+// image-only paragraphs are skipped. A document with no paragraph at all falls
+// back to its first heading.
+func extractDescription(content []byte) string {
+	doc := markdown.Parse(content, nil)
+
+	var fallback *plainText
+	for _, block := range doc.GetChildren() {
+		switch block.(type) {
+		case *ast.Paragraph:
+			text := inlinePlainText(block)
+			if text.len() > 0 {
+				return text.truncate(descriptionLimit)
+			}
+		case *ast.Heading:
+			if fallback == nil {
+				if text := inlinePlainText(block); text.len() > 0 {
+					fallback = text
+				}
+			}
+		}
+	}
+	if fallback != nil {
+		return fallback.truncate(descriptionLimit)
+	}
+	return ""
+}
+
+// plainText is inline text flattened to runes, with whitespace collapsed and
+// the extent of every link's text recorded so truncation can avoid them.
+type plainText struct {
+	runes     []rune
+	links     [][2]int // [start, end) rune offsets of each link's text
+	lastSpace bool
+}
+
+// inlinePlainText flattens a block's inline children to text. Link text and
+// inline code keep their words; the markup around them, images and inline HTML
+// are dropped.
+func inlinePlainText(block ast.Node) *plainText {
+	t := &plainText{lastSpace: true} // leading whitespace is trimmed by starting "in" a space
+	t.walk(block)
+	t.trimRight()
+	return t
+}
+
+func (t *plainText) walk(node ast.Node) {
+	for _, child := range node.GetChildren() {
+		switch n := child.(type) {
+		case *ast.Text:
+			t.write(html.UnescapeString(string(n.Literal)))
+		case *ast.Code:
+			t.write(string(n.Literal))
+		case *ast.Softbreak, *ast.Hardbreak:
+			t.write(" ")
+		case *ast.Image, *ast.HTMLSpan:
+			// Neither is prose: alt text reads oddly out of context, and raw
+			// HTML would arrive as tags.
+		case *ast.Link:
+			start := len(t.runes)
+			t.walk(n)
+			t.links = append(t.links, [2]int{start, len(t.runes)})
+		default:
+			t.walk(child)
+		}
+	}
+}
+
+// write appends s, folding any run of whitespace to a single space.
+func (t *plainText) write(s string) {
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			if !t.lastSpace {
+				t.runes = append(t.runes, ' ')
+				t.lastSpace = true
+			}
+			continue
+		}
+		t.runes = append(t.runes, r)
+		t.lastSpace = false
+	}
+}
+
+func (t *plainText) trimRight() {
+	for len(t.runes) > 0 && t.runes[len(t.runes)-1] == ' ' {
+		t.runes = t.runes[:len(t.runes)-1]
+	}
+}
+
+func (t *plainText) len() int { return len(t.runes) }
+
+// insideLink reports whether cutting before rune i would split a link's text,
+// and if so where that link starts.
+func (t *plainText) insideLink(i int) (int, bool) {
+	for _, l := range t.links {
+		if l[0] < i && i < l[1] {
+			return l[0], true
+		}
+	}
+	return 0, false
+}
+
+// truncate returns the text cut to at most limit runes. It prefers to end on a
+// sentence, with no ellipsis, as long as that keeps at least half the limit;
+// otherwise it cuts at the last word boundary and appends "...". Neither cut
+// lands inside a link's text. A single word longer than the limit has no
+// boundary to use, so it is cut on a rune with truncateRunes.
+func (t *plainText) truncate(limit int) string {
+	if len(t.runes) <= limit {
+		return string(t.runes)
+	}
+
+	for end := limit; end >= limit/2; end-- {
+		if !strings.ContainsRune(".!?", t.runes[end-1]) || t.runes[end] != ' ' {
+			continue
+		}
+		if _, split := t.insideLink(end); split {
+			continue
+		}
+		return string(t.runes[:end])
+	}
+
+	const ellipsis = "..."
+	budget := limit - len(ellipsis)
+	for end := budget; end > 0; end-- {
+		if t.runes[end] != ' ' {
+			continue
+		}
+		if start, split := t.insideLink(end); split {
+			end = start
+		}
+		cut := strings.TrimRight(string(t.runes[:end]), " ,;:—–-")
+		if cut == "" {
+			break
+		}
+		return cut + ellipsis
+	}
+
+	return truncateRunes(string(t.runes), limit)
+}
+
+// truncateRunes shortens s to at most limit characters, appending an ellipsis
+// when it cuts. It counts runes rather than bytes: this text lands in
+// <meta description> and og:description, and slicing a multi-byte rune down the
+// middle — an em dash or a curly quote straddling the cut — emits invalid UTF-8
+// that escaping cannot repair.
+func truncateRunes(s string, limit int) string {
+	if utf8.RuneCountInString(s) <= limit {
+		return s
+	}
+	return string([]rune(s)[:limit-3]) + "..."
+}
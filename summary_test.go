@@ -0,0 +1,194 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestExtractDescriptionPlainText covers the markup the old string-stripping
+// leaked into descriptions, and the identifiers it mangled.
+func TestExtractDescriptionPlainText(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "link syntax drops to its text",
+			in:   "I use [promptfoo](https://www.promptfoo.dev/) for evals.",
+			want: "I use promptfoo for evals.",
+		},
+		{
+			name: "inline code keeps its text, loses its backticks",
+			in:   "Call `generateSite` to build.",
+			want: "Call generateSite to build.",
+		},
+		{
+			name: "snake_case survives",
+			in:   "Set max_retries in the config.",
+			want: "Set max_retries in the config.",
+		},
+		{
+			name: "images and inline HTML are dropped",
+			in:   "![a diagram](/img/flow.png) The flow <em>starts</em> here.",
+			want: "The flow starts here.",
+		},
+		{
+			name: "soft line breaks become spaces",
+			in:   "One line\nwraps onto\nthe next.",
+			want: "One line wraps onto the next.",
+		},
+		{
+			name: "image-only paragraph is not a real paragraph",
+			in:   "![cover](/cover.png)\n\nThe actual opening.",
+			want: "The actual opening.",
+		},
+		{
+			name: "code blocks and HTML blocks are skipped",
+			in:   "```sh\necho hi\n```\n\n<div>raw</div>\n\nProse at last.",
+			want: "Prose at last.",
+		},
+		{
+			name: "entities decode to the characters they stand for",
+			in:   "Tom &amp; Jerry.",
+			want: "Tom & Jerry.",
+		},
+		{
+			name: "a heading is the fallback when there's no paragraph",
+			in:   "## Only a heading\n\n```go\nfunc main() {}\n```",
+			want: "Only a heading",
+		},
+		{
+			name: "nothing to describe",
+			in:   "```go\nfunc main() {}\n```",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractDescription([]byte(tt.in)); got != tt.want {
+				t.Errorf("extractDescription(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractDescriptionCutsAtWordBoundary checks a paragraph with no sentence
+// end in range is cut between words, not inside one.
+func TestExtractDescriptionCutsAtWordBoundary(t *testing.T) {
+	para := strings.Repeat("word ", 60)
+
+	got := extractDescription([]byte(para))
+
+	if !strings.HasSuffix(got, "word...") {
+		t.Errorf("expected a cut after a whole word, got %q", got)
+	}
+	if n := utf8.RuneCountInString(got); n > descriptionLimit {
+		t.Errorf("description is %d runes, want at most %d", n, descriptionLimit)
+	}
+}
+
+// TestExtractDescriptionNeverSplitsLinkText puts a link across the word-cut
+// point and checks the cut backs off to before it.
+func TestExtractDescriptionNeverSplitsLinkText(t *testing.T) {
+	para := strings.Repeat("x ", 65) + "see [the AWS documentation on VPC flow logs](https://docs.aws.amazon.com/) for more"
+
+	got := extractDescription([]byte(para))
+
+	if strings.Contains(got, "the AWS") && !strings.Contains(got, "flow logs") {
+		t.Errorf("description splits the link's text: %q", got)
+	}
+	if !strings.HasSuffix(got, "see...") {
+		t.Errorf("expected the cut to land before the link, got %q", got)
+	}
+}
+
+// TestExtractDescriptionPrefersSentenceEnd checks a sentence that fits is kept
+// whole, without an ellipsis, rather than cut to the last word that fits.
+func TestExtractDescriptionPrefersSentenceEnd(t *testing.T) {
+	first := "This first sentence is long enough to count as a proper description of the post on its own merits."
+	para := first + " The second sentence runs on well past the limit and so can't be kept in full."
+
+	if got := extractDescription([]byte(para)); got != first {
+		t.Errorf("extractDescription = %q, want the first sentence %q", got, first)
+	}
+}
+
+// TestExtractDescriptionIgnoresShortSentence checks a sentence end too early in
+// the text doesn't throw most of the allowance away.
+func TestExtractDescriptionIgnoresShortSentence(t *testing.T) {
+	para := "Short. " + strings.Repeat("then many more words ", 12)
+
+	got := extractDescription([]byte(para))
+	if got == "Short." {
+		t.Error("description stopped at a short first sentence")
+	}
+	if !strings.HasSuffix(got, "...") {
+		t.Errorf("expected a word cut with an ellipsis, got %q", got)
+	}
+}
+
+// TestSplitExcerpt covers the marker spellings accepted and the ones that are
+// deliberately not a marker.
+func TestSplitExcerpt(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+		ok             bool
+	}{
+		{"bare marker", "Teaser.\n<!--more-->\nRest.", "Teaser.\n", true},
+		{"spaced marker", "Teaser.\n\n  <!-- more -->  \n\nRest.", "Teaser.\n\n", true},
+		{"first marker wins", "A\n<!--more-->\nB\n<!--more-->\nC", "A\n", true},
+		{"no marker", "Just a post.", "", false},
+		{"marker inside a line is prose", "Write <!--more--> to split.", "", false},
+		{"marker inside a fence is code", "Mark it:\n\n```markdown\nTeaser.\n<!--more-->\n```\n\nDone.", "", false},
+		{"marker after a fence", "```\n<!--more-->\n```\nTeaser.\n<!--more-->\nRest.", "```\n<!--more-->\n```\nTeaser.\n", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := splitExcerpt([]byte(tt.in))
+			if ok != tt.ok || string(got) != tt.want {
+				t.Errorf("splitExcerpt(%q) = %q, %v; want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// TestProcessMarkdownFileExcerpt checks the excerpt is the rendered HTML before
+// the marker, links and formatting intact, and that the page keeps the whole
+// post.
+func TestProcessMarkdownFileExcerpt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2024-01-02-teaser.md")
+	source := "---\ntitle: Teaser\n---\nA **bold** start with [a link](/about.html).\n\n<!--more-->\n\nThe rest of the post."
+	if err := os.WriteFile(path, []byte(source), 0644); err != nil {
+		t.Fatalf("writing post: %v", err)
+	}
+
+	_, page, post, err := processMarkdownFile(path, testTemplate)
+	if err != nil {
+		t.Fatalf("processMarkdownFile: %v", err)
+	}
+	want := `<p>A <strong>bold</strong> start with <a href="/about.html">a link</a>.</p>`
+	if post.Excerpt != want {
+		t.Errorf("excerpt = %q, want %q", post.Excerpt, want)
+	}
+	assertContains(t, page, "A <strong>bold</strong> start", "The rest of the post.")
+}
+
+// TestRenderPostListExcerpts checks listings that ask for excerpts show them in
+// place of the description, and that the archive style doesn't.
+func TestRenderPostListExcerpts(t *testing.T) {
+	posts := []*BlogPost{
+		{Title: "Marked", OutputFile: "marked.html", Description: "One line.", Excerpt: "<p>A <em>rich</em> teaser.</p>"},
+		{Title: "Plain", OutputFile: "plain.html", Description: "Just a description."},
+	}
+
+	withExcerpts := renderPostList(posts, true)
+	assertContains(t, withExcerpts, `<div class="excerpt"><p>A <em>rich</em> teaser.</p></div>`, "<p>Just a description.</p>")
+	assertNotContains(t, withExcerpts, "<p>One line.</p>")
+
+	archive := renderPostList(posts, false)
+	assertContains(t, archive, "<p>One line.</p>")
+	assertNotContains(t, archive, "rich")
+}
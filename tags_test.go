@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/xml"
 	"os"
 	"path/filepath"
 	"strings"
@@ -79,9 +80,9 @@ func TestNormaliseTags(t *testing.T) {
 	}
 }
 
-// TestTagListUnmarshal confirms both frontmatter spellings parse: the
+// TestTagsFrontmatter confirms both frontmatter spellings of tags parse: the
 // space-separated scalar every post in content/ uses, and a YAML sequence.
-func TestTagListUnmarshal(t *testing.T) {
+func TestTagsFrontmatter(t *testing.T) {
 	tests := []struct {
 		name string
 		doc  string
@@ -110,7 +111,7 @@ func TestTagListUnmarshal(t *testing.T) {
 			if _, err := frontmatter.Parse(strings.NewReader(tt.doc), &meta); err != nil {
 				t.Fatalf("parsing frontmatter: %v", err)
 			}
-			got := normaliseTags(meta.Tags)
+			got := normaliseTags(paramTerms(meta.Params["tags"]))
 			if len(got) != len(tt.want) {
 				t.Fatalf("tags = %v, want %v", got, tt.want)
 			}
@@ -123,14 +124,19 @@ func TestTagListUnmarshal(t *testing.T) {
 	}
 }
 
+// testTags is the default tags taxonomy over reg.
+func testTags(reg termRegistry) *taxonomy {
+	return &taxonomy{taxonomyConfig: defaultSiteConfig().Taxonomies[0], registry: reg}
+}
+
 // TestRenderTagChips checks the markup, the link target, and that an untagged
 // post contributes nothing at all.
 func TestRenderTagChips(t *testing.T) {
-	if got := renderTagChips(nil); got != "" {
+	if got := testTags(termRegistry{}).renderChips(nil); got != "" {
 		t.Errorf("expected no markup for an untagged post, got %q", got)
 	}
 
-	got := renderTagChips([]string{"devops", "aws"})
+	got := testTags(termRegistry{}).renderChips([]string{"devops", "aws"})
 	for _, want := range []string{
 		`class="tag-list"`,
 		`href="/tags/devops.html"`,
@@ -152,23 +158,23 @@ func TestGroupByTag(t *testing.T) {
 	}
 
 	posts := []*BlogPost{
-		{Title: "A", Date: d("2023-01-01"), OutputFile: "a.html", Tags: []string{"aws", "devops"}},
-		{Title: "B", Date: d("2023-02-01"), OutputFile: "b.html", Tags: []string{"devops"}},
-		{Title: "C", Date: d("2023-03-01"), OutputFile: "c.html", Tags: []string{"zzz", "devops"}},
-		{Title: "About", OutputFile: "about.html", Tags: []string{"aws"}},
+		{Title: "A", Date: d("2023-01-01"), OutputFile: "a.html", Terms: map[string][]string{"tags": {"aws", "devops"}}},
+		{Title: "B", Date: d("2023-02-01"), OutputFile: "b.html", Terms: map[string][]string{"tags": {"devops"}}},
+		{Title: "C", Date: d("2023-03-01"), OutputFile: "c.html", Terms: map[string][]string{"tags": {"zzz", "devops"}}},
+		{Title: "About", OutputFile: "about.html", Terms: map[string][]string{"tags": {"aws"}}},
 	}
 
-	groups := groupByTag(posts)
+	groups := testTags(termRegistry{}).group(posts)
 	if len(groups) != 3 {
 		t.Fatalf("expected 3 tags, got %d", len(groups))
 	}
 
-	if groups[0].Tag != "devops" || len(groups[0].Posts) != 3 {
-		t.Errorf("expected devops with 3 posts first, got %s with %d", groups[0].Tag, len(groups[0].Posts))
+	if groups[0].Term != "devops" || len(groups[0].Posts) != 3 {
+		t.Errorf("expected devops with 3 posts first, got %s with %d", groups[0].Term, len(groups[0].Posts))
 	}
 	// aws and zzz both have one dated post, so they sort alphabetically.
-	if groups[1].Tag != "aws" || groups[2].Tag != "zzz" {
-		t.Errorf("expected aws then zzz, got %s then %s", groups[1].Tag, groups[2].Tag)
+	if groups[1].Term != "aws" || groups[2].Term != "zzz" {
+		t.Errorf("expected aws then zzz, got %s then %s", groups[1].Term, groups[2].Term)
 	}
 	// The undated About page carries the aws tag but must not be listed.
 	if len(groups[1].Posts) != 1 || groups[1].Posts[0].Title != "A" {
@@ -187,12 +193,12 @@ func TestGenerateTagPages(t *testing.T) {
 
 	date, _ := time.Parse("2006-01-02", "2023-01-15")
 	posts := []*BlogPost{
-		{Title: "First Post", Date: date, OutputFile: "first.html", Tags: []string{"devops", "aws"}},
+		{Title: "First Post", Date: date, OutputFile: "first.html", Terms: map[string][]string{"tags": {"devops", "aws"}}},
 	}
 
-	written, err := generateTagPages(posts, testTemplate, buildDir)
+	written, err := generateTaxonomyPages(testTags(termRegistry{}), posts, testTemplate, buildDir, defaultSiteConfig())
 	if err != nil {
-		t.Fatalf("generateTagPages: %v", err)
+		t.Fatalf("generateTaxonomyPages: %v", err)
 	}
 
 	wantPaths := map[string]bool{"tags/devops.html": true, "tags/aws.html": true, "tags.html": true}
@@ -234,9 +240,9 @@ func TestGenerateTagPagesUntagged(t *testing.T) {
 	date, _ := time.Parse("2006-01-02", "2023-01-15")
 	posts := []*BlogPost{{Title: "First Post", Date: date, OutputFile: "first.html"}}
 
-	written, err := generateTagPages(posts, testTemplate, buildDir)
+	written, err := generateTaxonomyPages(testTags(termRegistry{}), posts, testTemplate, buildDir, defaultSiteConfig())
 	if err != nil {
-		t.Fatalf("generateTagPages: %v", err)
+		t.Fatalf("generateTaxonomyPages: %v", err)
 	}
 	if len(written) != 0 {
 		t.Errorf("expected no tag pages, got %v", written)
@@ -271,12 +277,12 @@ func TestSlugifyTagCannotEscapeBuildDir(t *testing.T) {
 			continue // an empty slug never reaches a filename
 		}
 
-		path := tagPagePath(slug)
+		path := testTags(termRegistry{}).pagePath(slug)
 		if filepath.Dir(path) != "tags" {
-			t.Errorf("tagPagePath(slugifyTag(%q)) = %q, which lands outside tags/", in, path)
+			t.Errorf("pagePath(slugifyTag(%q)) = %q, which lands outside tags/", in, path)
 		}
 		if cleaned := filepath.Clean(path); cleaned != path {
-			t.Errorf("tagPagePath(slugifyTag(%q)) = %q, which is not already a clean path", in, path)
+			t.Errorf("pagePath(slugifyTag(%q)) = %q, which is not already a clean path", in, path)
 		}
 	}
 }
@@ -299,3 +305,53 @@ func TestPluralPosts(t *testing.T) {
 		}
 	}
 }
+
+// TestGenerateTagPagesWritesTagFeeds checks each tag gets an RSS feed limited
+// to its own posts, advertised from the tag page's head and body and listed on
+// tags.html.
+func TestGenerateTagPagesWritesTagFeeds(t *testing.T) {
+	buildDir := t.TempDir()
+
+	posts := []*BlogPost{
+		{Title: "Pipelines", Date: testDate(t, "2023-01-15"), OutputFile: "pipelines.html", Terms: map[string][]string{"tags": {"devops"}}},
+		{Title: "Prompts", Date: testDate(t, "2023-02-15"), OutputFile: "prompts.html", Terms: map[string][]string{"tags": {"ai"}}},
+		{Title: "Agents in CI", Date: testDate(t, "2023-03-15"), OutputFile: "agents.html", Terms: map[string][]string{"tags": {"ai", "devops"}}},
+	}
+
+	written, err := generateTaxonomyPages(testTags(termRegistry{}), posts, testMetaTemplate, buildDir, defaultSiteConfig())
+	if err != nil {
+		t.Fatalf("generateTaxonomyPages: %v", err)
+	}
+	for _, path := range written {
+		if strings.HasSuffix(path, ".xml") {
+			t.Errorf("tag feed %q reported as a page; feeds don't belong in the sitemap", path)
+		}
+	}
+
+	raw := readFile(t, filepath.Join(buildDir, "tags", "ai.xml"))
+	var feed rssDocument
+	if err := xml.Unmarshal([]byte(raw), &feed); err != nil {
+		t.Fatalf("tags/ai.xml does not parse: %v", err)
+	}
+	if len(feed.Channel.Items) != 2 {
+		t.Fatalf("ai feed has %d items, want the 2 ai posts", len(feed.Channel.Items))
+	}
+	if feed.Channel.Items[0].Title != "Agents in CI" || feed.Channel.Items[1].Title != "Prompts" {
+		t.Errorf("ai feed items = %+v, want the ai posts newest first", feed.Channel.Items)
+	}
+	if !strings.Contains(raw, `href="https://letsbuild.cloud/tags/ai.xml"`) {
+		t.Error("tag feed should carry its own URL as the atom self link")
+	}
+	if !strings.Contains(raw, "<link>https://letsbuild.cloud/tags/ai.html</link>") {
+		t.Error("tag feed channel should link the tag page")
+	}
+
+	page := readFile(t, filepath.Join(buildDir, "tags", "ai.html"))
+	assertContains(t, page,
+		`<link rel="alternate" type="application/rss+xml" title="LetsBuild.cloud — ai" href="/tags/ai.xml" />`,
+		`<a href="/tags/ai.xml">Subscribe to ai posts (RSS)</a>`,
+	)
+
+	index := readFile(t, filepath.Join(buildDir, "tags.html"))
+	assertContains(t, index, `<a href="/tags/ai.xml">ai</a>`, `<a href="/tags/devops.xml">devops</a>`)
+}
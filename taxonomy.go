@@ -0,0 +1,324 @@
+package main
+
+// Taxonomies. A taxonomy is a way of classifying posts that site.yaml declares
+// by name — tags, categories, the stack a post is built on — and every one gets
+// the same machinery: terms read from the frontmatter key of the same name and
+// normalised like tags, a registry of aliases and intros under content/<name>/,
+// chips on each post, a page and an RSS feed per term under <name>/, and an
+// index at <name>.html. Tags are the taxonomy a site has by default.
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// taxonomy is a configured taxonomy with its term registry loaded.
+type taxonomy struct {
+	taxonomyConfig
+	registry termRegistry
+}
+
+// loadTaxonomies pairs each configured taxonomy with the registry under
+// contentDir/<name>/.
+func loadTaxonomies(configs []taxonomyConfig, contentDir string) ([]*taxonomy, error) {
+	taxonomies := make([]*taxonomy, 0, len(configs))
+	for _, cfg := range configs {
+		reg, err := loadTermRegistry(filepath.Join(contentDir, cfg.Name))
+		if err != nil {
+			return nil, err
+		}
+		taxonomies = append(taxonomies, &taxonomy{taxonomyConfig: cfg, registry: reg})
+	}
+	return taxonomies, nil
+}
+
+// pagePath is the build-relative path of the listing page for one term.
+func (t *taxonomy) pagePath(term string) string {
+	return t.Name + "/" + term + ".html"
+}
+
+// feedPath is the build-relative path of the RSS feed for one term. It sits
+// beside the term page so the two read as a pair.
+func (t *taxonomy) feedPath(term string) string {
+	return t.Name + "/" + term + ".xml"
+}
+
+// indexPath is the build-relative path of the page listing every term.
+func (t *taxonomy) indexPath() string {
+	return t.Name + ".html"
+}
+
+// feedChannel describes the RSS feed limited to one term.
+func (t *taxonomy) feedChannel(term string) feedChannel {
+	name := t.registry.displayName(term)
+	return feedChannel{
+		title:       fmt.Sprintf("%s — %s", siteName, name),
+		link:        canonicalURL(t.pagePath(term)),
+		description: fmt.Sprintf("Posts %s %q on %s.", strings.ToLower(t.Label), name, siteName),
+		self:        t.feedPath(term),
+	}
+}
+
+// terms returns the post's terms in this taxonomy.
+func (t *taxonomy) terms(post *BlogPost) []string {
+	return post.Terms[t.Name]
+}
+
+// tags returns the post's terms in the tags taxonomy, which the feeds, the
+// related posts, search and the structured data read as its keywords. A site
+// that doesn't declare tags has none.
+func (p *BlogPost) tags() []string {
+	return p.Terms["tags"]
+}
+
+// assign reads the post's terms from its frontmatter, normalises them and
+// folds aliases into their canonical terms.
+func (t *taxonomy) assign(post *BlogPost) {
+	terms := t.registry.fold(normaliseTags(paramTerms(post.Params[t.Name])))
+	if len(terms) == 0 {
+		return
+	}
+	if post.Terms == nil {
+		post.Terms = make(map[string][]string)
+	}
+	post.Terms[t.Name] = terms
+}
+
+// paramTerms reads a taxonomy's frontmatter value the way tagList does: a
+// scalar is split on whitespace, a sequence is taken entry by entry, and
+// anything else holds no terms.
+func paramTerms(v interface{}) []string {
+	switch v := v.(type) {
+	case string:
+		return strings.Fields(v)
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if item != nil {
+				out = append(out, fmt.Sprint(item))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// renderChips renders terms as linked chips. It returns an empty string when
+// there are none so nothing but whitespace is added to the page.
+func (t *taxonomy) renderChips(terms []string) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("<nav class=\"tag-list\" aria-label=\"%s\">", html.EscapeString(t.Title)))
+	for _, term := range terms {
+		b.WriteString(fmt.Sprintf("<a class=\"tag\" href=\"/%s\">%s</a>",
+			html.EscapeString(t.pagePath(term)), html.EscapeString(t.registry.displayName(term))))
+	}
+	b.WriteString("</nav>")
+	return b.String()
+}
+
+// renderPostTerms renders a post's chips for every taxonomy, in the order
+// site.yaml lists them.
+func renderPostTerms(taxonomies []*taxonomy, post *BlogPost) string {
+	var b strings.Builder
+	for _, t := range taxonomies {
+		b.WriteString(t.renderChips(t.terms(post)))
+	}
+	return b.String()
+}
+
+// termGroup pairs a term with the posts filed under it, for the term index.
+type termGroup struct {
+	Term  string
+	Posts []*BlogPost
+}
+
+// group buckets dated posts by term, newest first within each bucket. The
+// result is ordered by post count descending, then alphabetically, so the terms
+// that carry the most writing lead the index.
+func (t *taxonomy) group(posts []*BlogPost) []termGroup {
+	byTerm := make(map[string][]*BlogPost)
+	for _, post := range datedPostsNewestFirst(posts) {
+		for _, term := range t.terms(post) {
+			byTerm[term] = append(byTerm[term], post)
+		}
+	}
+
+	groups := make([]termGroup, 0, len(byTerm))
+	for term, filed := range byTerm {
+		groups = append(groups, termGroup{Term: term, Posts: filed})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i].Posts) != len(groups[j].Posts) {
+			return len(groups[i].Posts) > len(groups[j].Posts)
+		}
+		return groups[i].Term < groups[j].Term
+	})
+	return groups
+}
+
+// generateTaxonomyPages writes one listing page and one RSS feed per term under
+// build/<name>/, plus the <name>.html index that links them all. A paginated
+// term continues under <name>/<term>/2.html and on. It returns the
+// build-relative paths of every page written, for the sitemap; the feeds are
+// not pages and are left out, and so are the redirect stubs written for each
+// alias in the registry.
+func generateTaxonomyPages(t *taxonomy, posts []*BlogPost, template, buildDir string, cfg siteConfig) ([]string, error) {
+	groups := t.group(posts)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(filepath.Join(buildDir, t.Name), 0755); err != nil {
+		return nil, fmt.Errorf("creating %s directory: %w", t.Name, err)
+	}
+
+	written := make([]string, 0, len(groups)+1)
+	feeds := make(map[string][]byte, len(groups))
+	for _, group := range groups {
+		info := t.registry.info(group.Term)
+		name := t.registry.displayName(group.Term)
+		title := t.Label + ": " + name
+		description := fmt.Sprintf("%s %s %q on %s.", pluralPosts(len(group.Posts)), strings.ToLower(t.Label), name, siteName)
+		if info != nil {
+			title = info.Name
+			if info.Description != "" {
+				description = info.Description
+			}
+		}
+
+		feedPath := t.feedPath(group.Term)
+		feed, err := marshalRSS(buildRSS(group.Posts, cfg.Feed, t.feedChannel(group.Term)))
+		if err != nil {
+			return written, fmt.Errorf("marshalling %s feed %s: %w", t.Singular, feedPath, err)
+		}
+		if err := os.WriteFile(filepath.Join(buildDir, filepath.FromSlash(feedPath)), feed, 0644); err != nil {
+			return written, fmt.Errorf("writing %s feed %s: %w", t.Singular, feedPath, err)
+		}
+		feeds[group.Term] = feed
+
+		pages := newPaginated(t.pagePath(group.Term), len(group.Posts), cfg.Pagination.Tags)
+		for n := 1; n <= pages.total; n++ {
+			var body strings.Builder
+			if n == 1 && info != nil && info.Intro != "" {
+				body.WriteString(fmt.Sprintf("<div class=\"tag-intro\">%s</div>", info.Intro))
+			}
+			body.WriteString(renderPostList(pages.slice(group.Posts, n), true))
+			body.WriteString(pages.pager(n))
+			body.WriteString(fmt.Sprintf("<p><a href=\"/%s\">&larr; All %s</a> &middot; <a href=\"/%s\">Subscribe to %s posts (RSS)</a></p>",
+				html.EscapeString(t.indexPath()), html.EscapeString(strings.ToLower(t.Title)),
+				html.EscapeString(feedPath), html.EscapeString(name)))
+
+			outputPath := pages.path(n)
+			pageTitle := pages.title(title, n)
+			trail := []crumb{{Name: t.Title, Path: t.indexPath()}}
+			page := renderPage(template, pageMeta{
+				Title:       pageTitle,
+				File:        filepath.Base(outputPath),
+				Content:     body.String(),
+				Description: description,
+				Canonical:   canonicalURL(outputPath),
+				HeadExtra: renderFeedAlternate(t.feedChannel(group.Term)) + pages.headLinks(n) +
+					collectionJSONLD(pageTitle, description, outputPath, trail, postItems(pages.slice(group.Posts, n), (n-1)*pages.size)),
+			})
+
+			fullPath := filepath.Join(buildDir, filepath.FromSlash(outputPath))
+			if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+				return written, fmt.Errorf("creating directory for %s page %s: %w", t.Singular, outputPath, err)
+			}
+			if err := os.WriteFile(fullPath, []byte(page), 0644); err != nil {
+				return written, fmt.Errorf("writing %s page %s: %w", t.Singular, outputPath, err)
+			}
+			written = append(written, outputPath)
+		}
+	}
+
+	// An alias was a term once, and its page and feed may be linked or
+	// subscribed to. The page becomes a redirect stub; the feed can't
+	// redirect, so it carries the canonical term's feed, whose self link tells
+	// a reader where it now lives.
+	stubs := 0
+	for _, alias := range t.registry.sortedAliases() {
+		term := t.registry.canonical(alias)
+		feed, ok := feeds[term]
+		if !ok {
+			continue // the canonical term has no posts, so nothing to send anyone to
+		}
+		stub := renderRedirectStub(t.pagePath(term), t.registry.displayName(term))
+		if err := os.WriteFile(filepath.Join(buildDir, filepath.FromSlash(t.pagePath(alias))), []byte(stub), 0644); err != nil {
+			return written, fmt.Errorf("writing redirect stub for %s %s: %w", t.Singular, alias, err)
+		}
+		if err := os.WriteFile(filepath.Join(buildDir, filepath.FromSlash(t.feedPath(alias))), feed, 0644); err != nil {
+			return written, fmt.Errorf("writing feed for %s alias %s: %w", t.Singular, alias, err)
+		}
+		stubs++
+	}
+
+	if err := generateTaxonomyIndex(t, groups, template, buildDir); err != nil {
+		return written, err
+	}
+	written = append(written, t.indexPath())
+
+	fmt.Printf("Generated %d %s pages, %d %s feeds, %d alias redirects and %s\n",
+		len(groups), t.Singular, len(groups), t.Singular, stubs, filepath.Join(buildDir, t.indexPath()))
+	return written, nil
+}
+
+// generateTaxonomyIndex writes <name>.html: every term as a chip carrying its
+// post count, most-used first, under its display name.
+func generateTaxonomyIndex(t *taxonomy, groups []termGroup, template, buildDir string) error {
+	var body strings.Builder
+	body.WriteString(fmt.Sprintf("<p>Every %s across the archive, most-used first.</p>", html.EscapeString(t.Singular)))
+	body.WriteString(fmt.Sprintf("<nav class=\"tag-cloud\" aria-label=\"All %s\">", html.EscapeString(strings.ToLower(t.Title))))
+	for _, group := range groups {
+		body.WriteString(fmt.Sprintf("<a class=\"tag\" href=\"/%s\">%s<span class=\"tag-count\">%d</span></a>",
+			html.EscapeString(t.pagePath(group.Term)), html.EscapeString(t.registry.displayName(group.Term)), len(group.Posts)))
+	}
+	body.WriteString("</nav>")
+	body.WriteString("<h2>Feeds</h2>")
+	body.WriteString(fmt.Sprintf("<p>Every %s has its own RSS feed, for following one topic without the rest.</p>", html.EscapeString(t.Singular)))
+	body.WriteString("<ul class=\"tag-feeds\">")
+	for _, group := range groups {
+		body.WriteString(fmt.Sprintf("<li><a href=\"/%s\">%s</a></li>",
+			html.EscapeString(t.feedPath(group.Term)), html.EscapeString(t.registry.displayName(group.Term))))
+	}
+	body.WriteString("</ul>")
+	body.WriteString("<p><a href=\"/posts.html\">&larr; All posts</a></p>")
+
+	description := fmt.Sprintf("Browse %s by %s — %d across the archive.", siteName, t.Singular, len(groups))
+	items := make([]ldListItem, 0, len(groups))
+	for i, group := range groups {
+		items = append(items, ldListItem{Type: "ListItem", Position: i + 1, Name: t.registry.displayName(group.Term), Item: canonicalURL(t.pagePath(group.Term))})
+	}
+	page := renderPage(template, pageMeta{
+		Title:       t.Title,
+		File:        t.indexPath(),
+		Description: description,
+		Canonical:   canonicalURL(t.indexPath()),
+		HeadExtra:   collectionJSONLD(t.Title, description, t.indexPath(), nil, items),
+		Content:     body.String(),
+	})
+
+	outputPath := filepath.Join(buildDir, t.indexPath())
+	if err := os.WriteFile(outputPath, []byte(page), 0644); err != nil {
+		return fmt.Errorf("writing %s index: %w", t.Singular, err)
+	}
+	return nil
+}
+
+// defaultTaxonomies is the default configuration's taxonomies with empty
+// registries, for rendering a post without the rest of the site.
+func defaultTaxonomies() []*taxonomy {
+	configs := defaultSiteConfig().Taxonomies
+	taxonomies := make([]*taxonomy, 0, len(configs))
+	for _, cfg := range configs {
+		taxonomies = append(taxonomies, &taxonomy{taxonomyConfig: cfg})
+	}
+	return taxonomies
+}
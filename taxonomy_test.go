@@ -0,0 +1,160 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testStack is a second taxonomy, configured the way site.yaml would.
+func testStack(reg termRegistry) *taxonomy {
+	return &taxonomy{
+		taxonomyConfig: taxonomyConfig{Name: "stack", Singular: "technology", Title: "Stack", Label: "Built with"},
+		registry:       reg,
+	}
+}
+
+func TestParamTerms(t *testing.T) {
+	tests := []struct {
+		name string
+		in   interface{}
+		want string
+	}{
+		{"scalar", "go  aws", "go,aws"},
+		{"sequence", []interface{}{"Go", "AWS CDK", nil, 3}, "Go,AWS CDK,3"},
+		{"missing", nil, ""},
+		{"mapping", map[interface{}]interface{}{"go": true}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(paramTerms(tt.in), ","); got != tt.want {
+				t.Errorf("paramTerms(%v) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTaxonomyAssign checks a non-tag taxonomy reads its own frontmatter key,
+// normalised and alias-folded like tags, and leaves tags alone.
+func TestTaxonomyAssign(t *testing.T) {
+	reg, err := loadTermRegistry(writeTagFiles(t, map[string]string{
+		"golang.md": "---\naliases: go\n---\n",
+	}))
+	if err != nil {
+		t.Fatalf("loadTermRegistry: %v", err)
+	}
+	stack := testStack(reg)
+
+	post := &BlogPost{Params: map[string]interface{}{"stack": "Go AWS_CDK go", "tags": []interface{}{"DevOps"}}}
+	stack.assign(post)
+	if got := strings.Join(stack.terms(post), ","); got != "aws-cdk,golang" {
+		t.Errorf("stack terms = %s, want aws-cdk,golang", got)
+	}
+	if post.tags() != nil {
+		t.Errorf("tags = %v, want them left to the tags taxonomy", post.tags())
+	}
+	testTags(termRegistry{}).assign(post)
+	if got := strings.Join(post.tags(), ","); got != "devops" {
+		t.Errorf("tags = %s, want devops", got)
+	}
+
+	bare := &BlogPost{}
+	stack.assign(bare)
+	if bare.Terms != nil {
+		t.Errorf("a post without the key got terms %v", bare.Terms)
+	}
+}
+
+func TestRenderPostTerms(t *testing.T) {
+	post := &BlogPost{Terms: map[string][]string{"tags": {"devops"}, "stack": {"go"}}}
+	got := renderPostTerms([]*taxonomy{testTags(termRegistry{}), testStack(termRegistry{})}, post)
+	assertContains(t, got,
+		`<nav class="tag-list" aria-label="Tags"><a class="tag" href="/tags/devops.html">devops</a></nav>`,
+		`<nav class="tag-list" aria-label="Stack"><a class="tag" href="/stack/go.html">go</a></nav>`,
+	)
+	if strings.Index(got, "Tags") > strings.Index(got, "Stack") {
+		t.Error("chips should follow the configured order")
+	}
+}
+
+// TestGenerateTaxonomyPagesSecondTaxonomy checks a second taxonomy gets the
+// whole of the tag machinery under its own name and wording.
+func TestGenerateTaxonomyPagesSecondTaxonomy(t *testing.T) {
+	buildDir := t.TempDir()
+	posts := []*BlogPost{
+		{Title: "Lambdas", Date: testDate(t, "2023-01-15"), OutputFile: "lambdas.html", Terms: map[string][]string{"stack": {"aws", "go"}}},
+		{Title: "Pipelines", Date: testDate(t, "2023-02-15"), OutputFile: "pipelines.html", Terms: map[string][]string{"stack": {"go"}}},
+		{Title: "Untagged", Date: testDate(t, "2023-03-15"), OutputFile: "untagged.html", Terms: map[string][]string{"tags": {"go"}}},
+	}
+
+	written, err := generateTaxonomyPages(testStack(termRegistry{}), posts, testMetaTemplate, buildDir, defaultSiteConfig())
+	if err != nil {
+		t.Fatalf("generateTaxonomyPages: %v", err)
+	}
+	if got := strings.Join(written, ","); got != "stack/go.html,stack/aws.html,stack.html" {
+		t.Errorf("written = %s", got)
+	}
+
+	page := readFile(t, filepath.Join(buildDir, "stack", "go.html"))
+	assertContains(t, page,
+		"<title>Built with: go</title>",
+		`2 posts built with &#34;go&#34;`,
+		`href="/stack.html">&larr; All stack</a>`,
+		`href="/stack/go.xml"`,
+	)
+	// Tags are a different taxonomy: a post tagged go is not built with it.
+	assertNotContains(t, page, "untagged.html")
+
+	feed := readFile(t, filepath.Join(buildDir, "stack", "go.xml"))
+	assertContains(t, feed, "Posts built with &#34;go&#34;", "lambdas.html", "pipelines.html")
+
+	index := readFile(t, filepath.Join(buildDir, "stack.html"))
+	assertContains(t, index,
+		"<title>Stack</title>",
+		"Every technology across the archive",
+		`aria-label="All stack"`,
+		`href="/stack/aws.html">aws<span class="tag-count">1</span>`,
+	)
+	if _, err := os.Stat(filepath.Join(buildDir, "tags.html")); !os.IsNotExist(err) {
+		t.Error("the stack taxonomy wrote tags.html")
+	}
+}
+
+// TestGenerateSiteTaxonomies builds a site whose site.yaml declares a second
+// taxonomy and checks its terms reach the post, its pages and the sitemap.
+func TestGenerateSiteTaxonomies(t *testing.T) {
+	testDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Could not change to test directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	config := "taxonomies:\n  - name: tags\n    singular: tag\n    title: Tags\n    label: Tagged\n  - name: stack\n    singular: technology\n    label: Built with\n"
+	if err := os.WriteFile(siteConfigPath, []byte(config), 0644); err != nil {
+		t.Fatalf("writing site.yaml: %v", err)
+	}
+	post := "---\ntitle: Go on Lambda\ntags: serverless\nstack: [Go, AWS]\n---\n\nA post.\n"
+	if err := os.WriteFile(filepath.Join(testDir, "content", "2023-06-01-go-lambda.md"), []byte(post), 0644); err != nil {
+		t.Fatalf("writing post: %v", err)
+	}
+
+	buildDir := filepath.Join(testDir, "build")
+	if err := generateSite(filepath.Join(testDir, "content"), buildDir, filepath.Join(testDir, "template.html"), nil); err != nil {
+		t.Fatalf("generateSite: %v", err)
+	}
+
+	assertContains(t, readFile(t, filepath.Join(buildDir, "2023-06-01-go-lambda.html")),
+		`href="/tags/serverless.html"`,
+		`<nav class="tag-list" aria-label="Stack"><a class="tag" href="/stack/aws.html">aws</a><a class="tag" href="/stack/go.html">go</a></nav>`,
+	)
+	assertContains(t, readFile(t, filepath.Join(buildDir, "stack", "go.html")), "2023-06-01-go-lambda.html")
+	assertContains(t, readFile(t, filepath.Join(buildDir, "sitemap.xml")),
+		"https://letsbuild.cloud/stack.html</loc>",
+		"https://letsbuild.cloud/stack/go.html</loc>",
+		"https://letsbuild.cloud/tags.html</loc>",
+	)
+}
@@ -0,0 +1,192 @@
+package main
+
+// Term registries. normaliseTags can fold "code_review" into "code-review",
+// but not "cicd" into "ci-cd": those are different words that mean the same
+// thing, and only the author knows it. A taxonomy's registry records what the
+// author knows, one file per term in a content directory named after the
+// taxonomy — content/tags/ for tags:
+//
+//	content/tags/ci-cd.md
+//	---
+//	name: CI/CD
+//	aliases: ci cicd
+//	---
+//	Continuous integration and delivery: pipelines, test jobs and ...
+//
+// The filename is the canonical term. Posts filed under an alias are filed
+// under it instead, the old alias URLs become redirect stubs, the name titles
+// the term page and the body introduces it. A term without a file works as it
+// always has.
+
+import (
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/adrg/frontmatter"
+)
+
+// termInfo is one registered term.
+type termInfo struct {
+	Term        string   // canonical slug, from the filename
+	Name        string   // display name; the slug when unset
+	Aliases     []string // slugs folded into Term
+	Description string   // meta description; from the intro when unset
+	Intro       string   // rendered HTML shown atop the term page
+}
+
+// termFrontMatter is the frontmatter of a term file.
+type termFrontMatter struct {
+	Name        string  `yaml:"name"`
+	Aliases     tagList `yaml:"aliases"`
+	Description string  `yaml:"description"`
+}
+
+// termRegistry maps terms to what the author declared about them. The zero
+// value is an empty registry under which every term stands for itself.
+type termRegistry struct {
+	terms   map[string]*termInfo
+	aliases map[string]string // alias slug -> canonical slug
+}
+
+// loadTermRegistry reads every term file in dir. A missing directory is an
+// empty registry. An alias claimed by two terms, or an alias that is itself a
+// registered term, is an error: either would leave a post's term depending on
+// which file was read last.
+func loadTermRegistry(dir string) (termRegistry, error) {
+	reg := termRegistry{terms: map[string]*termInfo{}, aliases: map[string]string{}}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return reg, nil
+	}
+	if err != nil {
+		return reg, fmt.Errorf("reading term registry %s: %w", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return reg, fmt.Errorf("reading %s: %w", path, err)
+		}
+		var meta termFrontMatter
+		body, err := frontmatter.Parse(strings.NewReader(string(raw)), &meta)
+		if err != nil {
+			return reg, fmt.Errorf("parsing frontmatter in %s: %w", path, err)
+		}
+
+		term := slugifyTag(strings.TrimSuffix(entry.Name(), ".md"))
+		if term == "" {
+			return reg, fmt.Errorf("%s: filename is not a usable term", path)
+		}
+		info := &termInfo{Term: term, Name: strings.TrimSpace(meta.Name), Description: strings.TrimSpace(meta.Description)}
+		if info.Name == "" {
+			info.Name = term
+		}
+		if strings.TrimSpace(string(body)) != "" {
+			info.Intro = strings.TrimSpace(string(renderMarkdown(body)))
+			if info.Description == "" {
+				info.Description = extractDescription(body)
+			}
+		}
+		for _, alias := range normaliseTags(meta.Aliases) {
+			if alias == term {
+				continue
+			}
+			if other, taken := reg.aliases[alias]; taken {
+				return reg, fmt.Errorf("%s: alias %q is already an alias of %q", path, alias, other)
+			}
+			reg.aliases[alias] = term
+			info.Aliases = append(info.Aliases, alias)
+		}
+		reg.terms[term] = info
+	}
+
+	for alias, term := range reg.aliases {
+		if _, registered := reg.terms[alias]; registered {
+			return reg, fmt.Errorf("%q is registered in its own file and as an alias of %q", alias, term)
+		}
+	}
+	return reg, nil
+}
+
+// canonical returns the term an alias folds into, or the term itself.
+func (r termRegistry) canonical(term string) string {
+	if to, ok := r.aliases[term]; ok {
+		return to
+	}
+	return term
+}
+
+// fold maps normalised terms to their canonical forms, keeping the result
+// deduplicated and sorted as normaliseTags leaves it: a post tagged both "ci"
+// and "cicd" ends up with one "ci-cd".
+func (r termRegistry) fold(terms []string) []string {
+	if len(r.aliases) == 0 {
+		return terms
+	}
+	seen := make(map[string]bool, len(terms))
+	out := make([]string, 0, len(terms))
+	for _, term := range terms {
+		term = r.canonical(term)
+		if !seen[term] {
+			seen[term] = true
+			out = append(out, term)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// info returns what the registry holds for term, or nil for an unregistered
+// one.
+func (r termRegistry) info(term string) *termInfo {
+	return r.terms[term]
+}
+
+// displayName is how term reads in headings and the term index.
+func (r termRegistry) displayName(term string) string {
+	if info := r.info(term); info != nil {
+		return info.Name
+	}
+	return term
+}
+
+// sortedAliases returns every alias, sorted, for writing redirect stubs in a
+// stable order.
+func (r termRegistry) sortedAliases() []string {
+	out := make([]string, 0, len(r.aliases))
+	for alias := range r.aliases {
+		out = append(out, alias)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// renderRedirectStub is a page that sends the reader, and any crawler, from a
+// retired URL to its replacement. GitHub Pages can't issue real redirects, so
+// this is the static equivalent: a canonical link for search engines, a
+// zero-second refresh for browsers, and a plain link for everything else.
+func renderRedirectStub(target, name string) string {
+	abs := canonicalURL(target)
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html lang="en">
+  <head>
+    <meta charset="UTF-8" />
+    <title>Moved to %[2]s</title>
+    <link rel="canonical" href="%[1]s" />
+    <meta name="robots" content="noindex" />
+    <meta http-equiv="refresh" content="0; url=/%[3]s" />
+  </head>
+  <body>
+    <p>This page has moved to <a href="/%[3]s">%[2]s</a>.</p>
+  </body>
+</html>
+`, html.EscapeString(abs), html.EscapeString(name), html.EscapeString(target))
+}
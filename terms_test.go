@@ -0,0 +1,198 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeTagFiles writes each name → body pair into a fresh registry directory.
+func writeTagFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestLoadTagRegistry(t *testing.T) {
+	dir := writeTagFiles(t, map[string]string{
+		"ci-cd.md":   "---\nname: CI/CD\naliases: ci CD ci_cd cicd\n---\n\nPipelines that build, test and ship. More after.\n",
+		"aws.md":     "---\nname: AWS\ndescription: Amazon Web Services.\n---\n",
+		"notes.txt":  "not a tag file",
+		"Go Lang.md": "Intro only.",
+	})
+
+	reg, err := loadTermRegistry(dir)
+	if err != nil {
+		t.Fatalf("loadTermRegistry: %v", err)
+	}
+
+	ci := reg.info("ci-cd")
+	if ci == nil {
+		t.Fatal("ci-cd was not registered")
+	}
+	if ci.Name != "CI/CD" {
+		t.Errorf("name = %q", ci.Name)
+	}
+	// ci_cd folds to ci-cd, the tag itself, so it is not an alias.
+	if got := strings.Join(ci.Aliases, ","); got != "cd,ci,cicd" {
+		t.Errorf("aliases = %s, want cd,ci,cicd", got)
+	}
+	assertContains(t, ci.Intro, "<p>Pipelines that build, test and ship.")
+	if ci.Description != "Pipelines that build, test and ship. More after." {
+		t.Errorf("description = %q, want it taken from the intro", ci.Description)
+	}
+
+	if aws := reg.info("aws"); aws == nil || aws.Description != "Amazon Web Services." || aws.Intro != "" {
+		t.Errorf("aws = %+v, want its own description and no intro", aws)
+	}
+	if goLang := reg.info("go-lang"); goLang == nil || goLang.Name != "go-lang" {
+		t.Errorf("go-lang = %+v, want the slug as its name", goLang)
+	}
+	if reg.displayName("python") != "python" {
+		t.Error("an unregistered tag should display as itself")
+	}
+}
+
+func TestLoadTagRegistryMissingDir(t *testing.T) {
+	reg, err := loadTermRegistry(filepath.Join(t.TempDir(), "tags"))
+	if err != nil {
+		t.Fatalf("loadTermRegistry on a missing directory: %v", err)
+	}
+	if got := reg.fold([]string{"ci", "aws"}); strings.Join(got, ",") != "ci,aws" {
+		t.Errorf("an empty registry changed tags: %v", got)
+	}
+}
+
+// TestLoadTagRegistryConflicts checks the two ways the registry can be
+// ambiguous both fail rather than resolving by file order.
+func TestLoadTagRegistryConflicts(t *testing.T) {
+	for name, files := range map[string]map[string]string{
+		"alias claimed twice": {
+			"ci-cd.md":      "---\naliases: ci\n---\n",
+			"continuous.md": "---\naliases: ci\n---\n",
+		},
+		"alias is a registered tag": {
+			"ci-cd.md": "---\naliases: ci\n---\n",
+			"ci.md":    "---\nname: CI\n---\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := loadTermRegistry(writeTagFiles(t, files)); err == nil {
+				t.Error("expected an error, got nil")
+			}
+		})
+	}
+}
+
+func TestTagRegistryFold(t *testing.T) {
+	reg, err := loadTermRegistry(writeTagFiles(t, map[string]string{
+		"ci-cd.md": "---\naliases: ci cd\n---\n",
+	}))
+	if err != nil {
+		t.Fatalf("loadTermRegistry: %v", err)
+	}
+
+	got := reg.fold([]string{"aws", "cd", "ci", "devops"})
+	if strings.Join(got, ",") != "aws,ci-cd,devops" {
+		t.Errorf("fold = %v, want both aliases merged into one sorted ci-cd", got)
+	}
+}
+
+// TestGenerateTagPagesWithRegistry checks a registered tag's page carries its
+// name and intro, and each alias gets a redirect stub and a copy of the feed
+// that the sitemap never sees.
+func TestGenerateTagPagesWithRegistry(t *testing.T) {
+	reg, err := loadTermRegistry(writeTagFiles(t, map[string]string{
+		"ci-cd.md": "---\nname: CI/CD\naliases: ci cd\n---\n\nShipping software, continuously.\n",
+	}))
+	if err != nil {
+		t.Fatalf("loadTermRegistry: %v", err)
+	}
+	buildDir := t.TempDir()
+	posts := []*BlogPost{
+		{Title: "Pipelines", OutputFile: "2023-01-01-pipes.html", Date: testDate(t, "2023-01-01"), Terms: map[string][]string{"tags": reg.fold([]string{"ci", "cd"})}},
+	}
+
+	written, err := generateTaxonomyPages(testTags(reg), posts, testMetaTemplate, buildDir, defaultSiteConfig())
+	if err != nil {
+		t.Fatalf("generateTaxonomyPages: %v", err)
+	}
+	if got := strings.Join(written, ","); got != "tags/ci-cd.html,tags.html" {
+		t.Errorf("written = %s, want no redirect stubs listed", got)
+	}
+
+	page := readFile(t, filepath.Join(buildDir, "tags", "ci-cd.html"))
+	assertContains(t, page,
+		"<title>CI/CD</title>",
+		`content="Shipping software, continuously."`,
+		`<div class="tag-intro"><p>Shipping software, continuously.</p></div>`,
+		`Subscribe to CI/CD posts (RSS)`,
+	)
+	assertContains(t, readFile(t, filepath.Join(buildDir, "tags", "ci-cd.xml")),
+		"<title>LetsBuild.cloud — CI/CD</title>", "Posts tagged &#34;CI/CD&#34;")
+	assertContains(t, testTags(reg).renderChips([]string{"ci-cd"}), `<a class="tag" href="/tags/ci-cd.html">CI/CD</a>`)
+
+	for _, alias := range []string{"ci", "cd"} {
+		stub := readFile(t, filepath.Join(buildDir, "tags", alias+".html"))
+		assertContains(t, stub,
+			`<link rel="canonical" href="https://letsbuild.cloud/tags/ci-cd.html" />`,
+			`<meta http-equiv="refresh" content="0; url=/tags/ci-cd.html" />`,
+			`<meta name="robots" content="noindex" />`,
+		)
+		feed := readFile(t, filepath.Join(buildDir, "tags", alias+".xml"))
+		if feed != readFile(t, filepath.Join(buildDir, "tags", "ci-cd.xml")) {
+			t.Errorf("tags/%s.xml is not the ci-cd feed", alias)
+		}
+	}
+
+	index := readFile(t, filepath.Join(buildDir, "tags.html"))
+	assertContains(t, index, `<a class="tag" href="/tags/ci-cd.html">CI/CD<span class="tag-count">1</span></a>`)
+}
+
+// TestGenerateSiteFoldsTagAliases runs the whole build with a registry under
+// content/tags/ and checks a post tagged with aliases is filed under the
+// canonical tag everywhere.
+func TestGenerateSiteFoldsTagAliases(t *testing.T) {
+	testDir, cleanup := setupTestEnv(t)
+	defer cleanup()
+
+	contentDir := filepath.Join(testDir, "content")
+	if err := os.MkdirAll(filepath.Join(contentDir, "tags"), 0755); err != nil {
+		t.Fatalf("creating tag registry: %v", err)
+	}
+	files := map[string]string{
+		filepath.Join("tags", "ci-cd.md"): "---\nname: CI/CD\naliases: ci cd\n---\n",
+		"2023-04-01-pipes.md":             "---\ntitle: Pipes\ntags: ci cd aws\n---\nBody.",
+	}
+	for name, body := range files {
+		if err := os.WriteFile(filepath.Join(contentDir, name), []byte(body), 0644); err != nil {
+			t.Fatalf("writing %s: %v", name, err)
+		}
+	}
+
+	originalWd, _ := os.Getwd()
+	if err := os.Chdir(testDir); err != nil {
+		t.Fatalf("Could not change to test directory: %v", err)
+	}
+	defer os.Chdir(originalWd)
+
+	buildDir := filepath.Join(testDir, "build")
+	if err := generateSite(contentDir, buildDir, filepath.Join(testDir, "template.html"), nil); err != nil {
+		t.Fatalf("generateSite: %v", err)
+	}
+
+	post := readFile(t, filepath.Join(buildDir, "2023-04-01-pipes.html"))
+	assertContains(t, post, `href="/tags/ci-cd.html"`)
+	assertNotContains(t, post, `href="/tags/ci.html"`, `href="/tags/cd.html"`)
+
+	sitemap := readFile(t, filepath.Join(buildDir, "sitemap.xml"))
+	assertContains(t, sitemap, "https://letsbuild.cloud/tags/ci-cd.html")
+	assertNotContains(t, sitemap, "https://letsbuild.cloud/tags/ci.html")
+	assertContains(t, readFile(t, filepath.Join(buildDir, "tags", "ci.html")), "url=/tags/ci-cd.html")
+}